@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"syscall"
 )
 
 type Filesystem struct {
@@ -17,10 +18,13 @@ func New(baseDir string) *Filesystem {
 	return &Filesystem{baseDir}
 }
 
-func (f Filesystem) CreateDirectory(relativePath string) error {
-	cmd := fmt.Sprintf("mkdir -p %s", relativePath)
-	_, err := execute(cmd, "")
-	return err
+// CreateDirectory creates path and any missing parents, relative paths are
+// taken from the base directory.
+func (f Filesystem) CreateDirectory(path string) error {
+	if path == "" {
+		return errors.New("path is empty")
+	}
+	return os.MkdirAll(f.resolve(path), 0o755)
 }
 
 func (f Filesystem) Exists(path string) (bool, error) {
@@ -34,29 +38,146 @@ func (f Filesystem) Exists(path string) (bool, error) {
 	return false, err
 }
 
-func (f Filesystem) ListDirectories() ([]string, error) {
-	cmd := "ls -l -d */*/*/ | awk '{print($9)}'"
-	output, err := execute(cmd, f.baseDir)
+// maxGroupDepth bounds how deep ListDirectories looks for repos of owners
+// nested in groups, GitLab allows 20 levels of subgroups.
+const maxGroupDepth = 20
+
+// ListDirectories returns the repo directories beneath relativePath: those
+// three levels down, i.e. <owner>/<lang>/<name>, and for owners nested in
+// groups (e.g. GitLab's <group>/<subgroup>/<lang>/<name>) the git repos found
+// deeper down. Top level directories named in skip are left out.
+func (f Filesystem) ListDirectories(relativePath string, skip ...string) ([]string, error) {
+	root := filepath.Join(f.baseDir, relativePath)
+	entries, err := os.ReadDir(root)
 	if err != nil {
 		return nil, err
 	}
 
 	dirs := make([]string, 0)
-	for _, line := range strings.Split(output, "\n") {
-		if line != "" {
-			dir := filepath.Join(f.baseDir, line)
-			dirs = append(dirs, dir)
+	for _, e := range entries {
+		if !e.IsDir() || contains(skip, e.Name()) {
+			continue
 		}
+		dirs = append(dirs, f.findRepos(filepath.Join(root, e.Name()), 1)...)
 	}
 
 	return dirs, nil
 }
 
+// findRepos returns the repo directories beneath dir, which is depth levels
+// below the root. A directory three or more levels down is a repo unless it
+// only holds directories that lead to git repos, in which case it is part of
+// a group's namespace and those repos are returned instead.
+func (f Filesystem) findRepos(dir string, depth int) []string {
+	if depth >= 3 {
+		if isGitRepo(dir) {
+			return []string{dir}
+		}
+		if depth >= 3+maxGroupDepth || !onlyDirectories(dir) {
+			return []string{dir}
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	rv := make([]string, 0)
+	for _, e := range entries {
+		if isDir(filepath.Join(dir, e.Name()), e) && !strings.HasPrefix(e.Name(), ".") {
+			rv = append(rv, f.findRepos(filepath.Join(dir, e.Name()), depth+1)...)
+		}
+	}
+
+	if depth < 3 {
+		return rv
+	}
+
+	// a namespace directory with no repos beneath it is reported as is,
+	// otherwise only its repos are
+	repos := make([]string, 0)
+	for _, d := range rv {
+		if isGitRepo(d) {
+			repos = append(repos, d)
+		}
+	}
+	if len(repos) == 0 {
+		return []string{dir}
+	}
+	return repos
+}
+
+func isGitRepo(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// onlyDirectories reports whether dir holds nothing but directories, hidden
+// entries aside.
+func onlyDirectories(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), ".") && !isDir(filepath.Join(dir, e.Name()), e) {
+			return false
+		}
+	}
+	return true
+}
+
+// isDir follows symlinks, as the three level glob this replaced did.
+func isDir(path string, e os.DirEntry) bool {
+	if e.IsDir() {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func contains(vals []string, val string) bool {
+	for _, v := range vals {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
+
+// ListHostDirectories returns the names of the top level directories that
+// namespace repos from hosts other than github.com. Host names always contain
+// a dot while GitHub logins never do.
+func (f Filesystem) ListHostDirectories() ([]string, error) {
+	entries, err := os.ReadDir(f.baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make([]string, 0)
+	for _, e := range entries {
+		if e.IsDir() && strings.Contains(e.Name(), ".") && !strings.HasPrefix(e.Name(), ".") {
+			hosts = append(hosts, e.Name())
+		}
+	}
+
+	return hosts, nil
+}
+
+// DeleteDir removes path and everything beneath it. Only paths strictly
+// inside the base directory are removed, anything else is refused.
 func (f Filesystem) DeleteDir(path string) error {
 	if path == "" {
 		return errors.New("path is empty, skipping for safety")
 	}
 
+	path = f.resolve(path)
+	if !f.contains(path) {
+		return fmt.Errorf("refusing to delete %s, it isn't inside %s", path, f.baseDir)
+	}
+
 	exists, err := f.Exists(path)
 	if err != nil {
 		return fmt.Errorf("filesystem.Exists failed: %w", err)
@@ -66,23 +187,52 @@ func (f Filesystem) DeleteDir(path string) error {
 		return nil
 	}
 
-	cmd := "rm -r " + path
-	_, err = execute(cmd, f.baseDir)
-	return err
+	return os.RemoveAll(path)
 }
 
+// MoveDir renames existingPath to newPath, failing rather than merging into or
+// replacing a newPath that already exists.
 func (f Filesystem) MoveDir(existingPath, newPath string) error {
-	cmd := fmt.Sprintf("mv %s %s", existingPath, newPath)
-	_, err := execute(cmd, f.baseDir)
+	exists, err := f.Exists(newPath)
+	if err != nil {
+		return fmt.Errorf("filesystem.Exists failed: %w", err)
+	} else if exists {
+		return fmt.Errorf("%s already exists", newPath)
+	}
+
+	err = os.Rename(existingPath, newPath)
+	if errors.Is(err, syscall.EXDEV) {
+		// rename can't cross filesystems, mv copies instead
+		return exec.Command("mv", "--", existingPath, newPath).Run()
+	}
 	return err
 }
 
-func execute(cmd, workingDir string) (string, error) {
-	c := exec.Command("bash", "-c", cmd)
-	if workingDir != "" {
-		c.Dir = workingDir
+// PruneDir removes path if it is an empty directory, anything else is left
+// alone.
+func (f Filesystem) PruneDir(path string) error {
+	entries, err := os.ReadDir(path)
+	if err != nil || len(entries) > 0 {
+		return nil
+	}
+	return os.Remove(path)
+}
+
+// resolve cleans path, joining relative paths onto the base directory.
+func (f Filesystem) resolve(path string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(f.baseDir, path)
+	}
+	return filepath.Clean(path)
+}
+
+// contains reports whether path is beneath the base directory, the base
+// directory itself doesn't count.
+func (f Filesystem) contains(path string) bool {
+	if f.baseDir == "" {
+		return false
 	}
 
-	b, err := c.Output()
-	return string(b), err
+	rel, err := filepath.Rel(filepath.Clean(f.baseDir), path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
 }
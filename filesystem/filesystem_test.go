@@ -0,0 +1,144 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCreateDirectory(t *testing.T) {
+	base := t.TempDir()
+	f := New(base)
+
+	// names come from remotes, none of these may be interpreted by a shell
+	for _, name := range []string{"with space", "semi;colon", "$(touch pwned)", "-rf", "a/b/c"} {
+		path := filepath.Join(base, "owner", name)
+		if err := f.CreateDirectory(path); err != nil {
+			t.Errorf("CreateDirectory(%q) failed: %v", path, err)
+		} else if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			t.Errorf("CreateDirectory(%q) didn't create it: %v", path, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(base, "pwned")); err == nil {
+		t.Error("a directory name was run as a command")
+	}
+
+	if err := f.CreateDirectory("relative/dir"); err != nil {
+		t.Errorf("CreateDirectory of a relative path failed: %v", err)
+	} else if _, err := os.Stat(filepath.Join(base, "relative", "dir")); err != nil {
+		t.Errorf("relative path wasn't created beneath the base directory: %v", err)
+	}
+
+	if err := f.CreateDirectory(""); err == nil {
+		t.Error("CreateDirectory of an empty path succeeded")
+	}
+}
+
+func TestDeleteDir(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "code")
+	outside := filepath.Join(root, "outside")
+	f := New(base)
+
+	repo := filepath.Join(base, "owner", "go", "with space")
+	for _, dir := range []string{repo, outside} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.DeleteDir(repo); err != nil {
+		t.Fatalf("DeleteDir(%q) failed: %v", repo, err)
+	}
+	if _, err := os.Stat(repo); !os.IsNotExist(err) {
+		t.Errorf("%s still exists: %v", repo, err)
+	}
+
+	if err := f.DeleteDir(repo); err != nil {
+		t.Errorf("DeleteDir of a missing directory failed: %v", err)
+	}
+
+	for _, path := range []string{"", base, base + "/", outside, filepath.Join(base, "..", "outside"), "../outside", root} {
+		if err := f.DeleteDir(path); err == nil {
+			t.Errorf("DeleteDir(%q) succeeded, want it refused", path)
+		}
+	}
+
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("%s was deleted: %v", outside, err)
+	}
+	if _, err := os.Stat(base); err != nil {
+		t.Errorf("the base directory was deleted: %v", err)
+	}
+}
+
+func TestListDirectories(t *testing.T) {
+	base := t.TempDir()
+	mkdir := func(path string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(base, path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	touch := func(path string) {
+		t.Helper()
+		mkdir(filepath.Dir(path))
+		if err := os.WriteFile(filepath.Join(base, path), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mkdir("me/go/repo/.git")
+	mkdir("me/go/not-git")
+	touch("me/go/scratch/notes.txt")
+	mkdir("gitlab.com/group/go/flat/.git")
+	mkdir("gitlab.com/group/sub/go/nested/.git")
+	mkdir("gitlab.com/group/sub/deeper/rust/deepest/.git")
+	mkdir("gitlab.com/group/sub/deeper/rust/.cache/ignored/.git")
+	touch("gitlab.com/stray.txt")
+
+	f := New(base)
+	tests := []struct {
+		root string
+		skip []string
+		want []string
+	}{
+		{
+			root: "",
+			skip: []string{"gitlab.com"},
+			want: []string{"me/go/not-git", "me/go/repo", "me/go/scratch"},
+		},
+		{
+			root: "gitlab.com",
+			want: []string{
+				"gitlab.com/group/go/flat",
+				"gitlab.com/group/sub/deeper/rust/deepest",
+				"gitlab.com/group/sub/go/nested",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		dirs, err := f.ListDirectories(tt.root, tt.skip...)
+		if err != nil {
+			t.Fatalf("ListDirectories(%q) failed: %v", tt.root, err)
+		}
+
+		got := make([]string, 0)
+		for _, d := range dirs {
+			rel, _ := filepath.Rel(base, d)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		sort.Strings(got)
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ListDirectories(%q) = %v, want %v", tt.root, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Credential is what a git credential helper returned for a host.
+type Credential struct {
+	Username, Password string
+}
+
+// CredentialFill asks the configured credential helpers for the https
+// credentials of username, or any user when empty, on host. Git is told not
+// to prompt, so a host without stored credentials returns nil rather than
+// blocking on a terminal.
+func (c Git) CredentialFill(ctx context.Context, host, username string) (*Credential, error) {
+	input := fmt.Sprintf("protocol=https\nhost=%s\n", host)
+	if username != "" {
+		input += fmt.Sprintf("username=%s\n", username)
+	}
+	input += "\n"
+	env := []string{"GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS="}
+
+	o, err := c.runner.runWithInput(ctx, "", env, input, "-c", "credential.interactive=false", "credential", "fill")
+	if err != nil {
+		// git exits 128 when no helper has credentials and it may not prompt
+		if isExitCode(err, 128) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	rv := &Credential{}
+	for _, line := range strings.Split(o, "\n") {
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		switch k {
+		case "username":
+			rv.Username = v
+		case "password":
+			rv.Password = v
+		}
+	}
+
+	if rv.Password == "" {
+		return nil, nil
+	}
+	return rv, nil
+}
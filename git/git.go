@@ -1,87 +1,304 @@
 package git
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Git struct {
+	runner runner
 }
 
 func New() *Git {
-	return &Git{}
+	return &Git{runner{}}
 }
 
-// TODO: add support for multiple remote repos
-func (c Git) GetSshUrl(path string) (string, error) {
-	gitCmd := "git remote | xargs git remote get-url"
-	cmd := exec.Command("bash", "-c", gitCmd)
-	cmd.Dir = path
-	o, err := cmd.Output()
-
+// GetSshUrl returns the url of the primary remote (see PrimaryRemote), or ""
+// if the repo has no remotes.
+func (c Git) GetSshUrl(ctx context.Context, path string) (string, error) {
+	remotes, err := c.GetRemotes(ctx, path)
 	if err != nil {
 		return "", err
 	}
 
-	return strings.Split(string(o), "\n")[0], nil
+	if r := PrimaryRemote(remotes); r != nil {
+		return r.URL, nil
+	}
+	return "", nil
+}
+
+func (c Git) Clone(ctx context.Context, url, path string) error {
+	_, err := c.runner.run(ctx, path, "clone", "--", url)
+	return err
 }
 
-func (c Git) Clone(url, path string) error {
-	cmd := fmt.Sprintf("/usr/bin/git clone %s", url)
-	_, err := execute(cmd, path)
+// Init creates an empty repo in path, re-running it on an existing repo is
+// harmless.
+func (c Git) Init(ctx context.Context, path string) error {
+	_, err := c.runner.run(ctx, path, "init", "--quiet")
 	return err
 }
 
-func (c Git) HasUncommittedChanges(path string) (bool, error) {
-	gitCmd := "git status | grep 'nothing to commit, working tree clean' | wc -l"
+func (c Git) AddRemote(ctx context.Context, path, name, url string) error {
+	_, err := c.runner.run(ctx, path, "remote", "add", name, url)
+	return err
+}
 
-	cmd := exec.Command("bash", "-c", gitCmd)
-	cmd.Dir = path
-	o, err := cmd.Output()
+// CommitAll stages everything not ignored and commits it, creating an empty
+// commit if there is nothing to stage.
+func (c Git) CommitAll(ctx context.Context, path, msg string) error {
+	if _, err := c.runner.run(ctx, path, "add", "--all"); err != nil {
+		return err
+	}
 
+	_, err := c.runner.run(ctx, path, "commit", "--quiet", "--allow-empty", "-m", msg)
+	return err
+}
+
+// PushUpstream pushes the current branch to remote and sets it as the
+// branch's upstream.
+func (c Git) PushUpstream(ctx context.Context, path, remote string) error {
+	_, err := c.runner.run(ctx, path, "push", "--quiet", "--set-upstream", remote, "HEAD")
+	return err
+}
+
+func (c Git) HasUncommittedChanges(ctx context.Context, path string) (bool, error) {
+	s, err := c.Status(ctx, path)
 	if err != nil {
 		return false, err
 	}
 
-	return strings.TrimSpace(string(o)) != "1", nil
+	return !s.Clean(), nil
 }
 
-func (c Git) PushLocalChanges(path string) error {
-	hasChanges, err := c.HasUncommittedChanges(path)
-	if err != nil || !hasChanges {
+// PullLatest fetches and fast-forwards the current branch, it fails rather
+// than creating a merge commit when the branch has diverged.
+func (c Git) PullLatest(ctx context.Context, path string) error {
+	if err := c.Fetch(ctx, path); err != nil {
 		return err
 	}
 
-	_, err = execute("git add . && git commit -m 'work in progress' && git push", path)
+	_, err := c.runner.run(ctx, path, "pull", "--ff-only")
 	return err
 }
 
-func (c Git) PullLatest(path string) error {
-	_, err := execute("git fetch && git pull", path)
+func (c Git) Fetch(ctx context.Context, path string) error {
+	_, err := c.runner.run(ctx, path, "fetch", "--quiet")
 	return err
 }
 
-func (c Git) HasMergeConflicts(path string) (bool, error) {
-	return false, nil
+// Push pushes the current branch to its upstream.
+func (c Git) Push(ctx context.Context, path string) error {
+	_, err := c.runner.run(ctx, path, "push", "--quiet")
+	return err
 }
 
-func (c Git) GetCommitHashes(path string) ([]string, error) {
-	// TODO: implement me
-	//return execute("git log | head -n 1 | awk '{print($2)}'", path)
-	return []string{}, nil
+// HasMergeConflicts reports whether the index has unmerged entries or a
+// merge, rebase, cherry-pick or revert is in progress.
+func (c Git) HasMergeConflicts(ctx context.Context, path string) (bool, error) {
+	unmerged, err := c.runner.run(ctx, path, "ls-files", "--unmerged")
+	if err != nil {
+		return false, err
+	}
+
+	if strings.TrimSpace(unmerged) != "" {
+		return true, nil
+	}
+
+	op, err := c.InProgressOperation(ctx, path)
+	return op != "", err
 }
 
-func (c Git) GetBranchName(path string) (string, error) {
+// InProgressOperation returns "merge", "rebase", "cherry-pick" or "revert"
+// if one of them has been started and not yet concluded, or "" otherwise.
+func (c Git) InProgressOperation(ctx context.Context, path string) (string, error) {
+	gitDir, err := c.runner.run(ctx, path, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", err
+	}
+
+	markers := []struct{ file, op string }{
+		{"MERGE_HEAD", "merge"},
+		{"rebase-merge", "rebase"},
+		{"rebase-apply", "rebase"},
+		{"CHERRY_PICK_HEAD", "cherry-pick"},
+		{"REVERT_HEAD", "revert"},
+	}
+	for _, m := range markers {
+		if _, err := os.Stat(filepath.Join(strings.TrimSpace(gitDir), m.file)); err == nil {
+			return m.op, nil
+		}
+	}
+
 	return "", nil
 }
 
-func execute(cmd, workingDir string) (string, error) {
-	c := exec.Command("bash", "-c", cmd)
-	if workingDir != "" {
-		c.Dir = workingDir
+type Commit struct {
+	Hash, Author, Subject string
+	Date                  time.Time
+}
+
+// ShortHash is the abbreviated hash git prints by default.
+func (c Commit) ShortHash() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// GetCommits returns up to n of the most recent commits reachable from HEAD,
+// newest first. Repos without any commits return an empty slice.
+func (c Git) GetCommits(ctx context.Context, path string, n int) ([]Commit, error) {
+	if _, err := c.runner.run(ctx, path, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		if isExitCode(err, 1) {
+			return []Commit{}, nil
+		}
+		return nil, err
+	}
+
+	// fields are separated by the ascii unit separator, which can't appear in
+	// names or subjects
+	o, err := c.runner.run(ctx, path, "log", "-n", strconv.Itoa(n), "--format=%H%x1f%an%x1f%aI%x1f%s")
+	if err != nil {
+		return nil, err
+	}
+
+	rv := make([]Commit, 0)
+	for _, line := range strings.Split(strings.TrimSpace(o), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+
+		date, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("time.Parse failed: %w", err)
+		}
+		rv = append(rv, Commit{fields[0], fields[1], fields[3], date})
+	}
+
+	return rv, nil
+}
+
+func (c Git) GetCommitHashes(ctx context.Context, path string, n int) ([]string, error) {
+	commits, err := c.GetCommits(ctx, path, n)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := make([]string, 0)
+	for _, commit := range commits {
+		rv = append(rv, commit.Hash)
+	}
+	return rv, nil
+}
+
+// GetBranchName returns the checked out branch, or "HEAD detached at <hash>"
+// when no branch is checked out.
+func (c Git) GetBranchName(ctx context.Context, path string) (string, error) {
+	branch, err := c.runner.run(ctx, path, "symbolic-ref", "--short", "--quiet", "HEAD")
+	if err == nil {
+		return strings.TrimSpace(branch), nil
+	}
+
+	if !isExitCode(err, 1) {
+		return "", err
+	}
+
+	hash, err := c.runner.run(ctx, path, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return "HEAD detached at " + strings.TrimSpace(hash), nil
+}
+
+// Error is returned when git exits unsuccessfully, it carries git's stderr
+// since that is where the actual reason ends up.
+type Error struct {
+	Args   []string
+	Stderr string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("git %s: %s", strings.Join(e.Args, " "), e.Err)
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func isExitCode(err error, code int) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == code
+}
+
+// runner execs git directly with an argument vector, never through a shell,
+// so paths and urls are passed through verbatim.
+type runner struct{}
+
+func (r runner) run(ctx context.Context, dir string, args ...string) (string, error) {
+	return r.runWithEnv(ctx, dir, nil, args...)
+}
+
+// runWithEnv runs git with env appended to the current environment.
+func (r runner) runWithEnv(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	return r.runWithInput(ctx, dir, env, "", args...)
+}
+
+// runWithInput is runWithEnv with input fed to git's stdin.
+func (r runner) runWithInput(ctx context.Context, dir string, env []string, input string, args ...string) (string, error) {
+	bin, err := binary()
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Dir = dir
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", &Error{args, strings.TrimSpace(stderr.String()), err}
+	}
+
+	return stdout.String(), nil
+}
+
+// binary finds git on PATH, falling back to GIT_EXEC_PATH for installs that
+// only expose git there.
+func binary() (string, error) {
+	bin, err := exec.LookPath("git")
+	if err == nil {
+		return bin, nil
+	}
+
+	if execPath := os.Getenv("GIT_EXEC_PATH"); execPath != "" {
+		candidate := filepath.Join(execPath, "git")
+		if _, statErr := os.Stat(candidate); statErr == nil {
+			return candidate, nil
+		}
 	}
 
-	b, err := c.Output()
-	return string(b), err
+	return "", fmt.Errorf("git not found: %w", err)
 }
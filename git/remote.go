@@ -0,0 +1,106 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type (
+	Remote struct {
+		Name, URL string
+	}
+
+	// Url is a remote url broken into the parts that identify a repo,
+	// regardless of whether it was given as ssh, scp-like or https.
+	Url struct {
+		Host, Owner, Name string
+	}
+)
+
+// Key identifies the repo case-insensitively, as hosts treat owner and
+// repo names.
+func (u Url) Key() string {
+	return strings.ToLower(u.Host + "/" + u.Owner + "/" + u.Name)
+}
+
+// ParseUrl parses scp-like (git@host:owner/name.git) and scheme based
+// (ssh://, https://) remote urls. Owners may contain slashes, as GitLab
+// subgroups do.
+func ParseUrl(raw string) (*Url, error) {
+	var host, path string
+	if u, err := url.Parse(raw); err == nil && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	} else if at, colon := strings.Index(raw, "@"), strings.Index(raw, ":"); at >= 0 && colon > at {
+		host, path = raw[at+1:colon], raw[colon+1:]
+	} else {
+		return nil, fmt.Errorf("unrecognised remote url: %s", raw)
+	}
+
+	p := strings.Split(strings.Trim(path, "/"), "/")
+	if len(p) < 2 {
+		return nil, errors.New("remote url is missing owner or name: " + raw)
+	}
+
+	return &Url{
+		Host:  host,
+		Owner: strings.Join(p[:len(p)-1], "/"),
+		Name:  strings.TrimSuffix(p[len(p)-1], ".git"),
+	}, nil
+}
+
+// GetRemotes returns every remote's name and fetch url.
+func (c Git) GetRemotes(ctx context.Context, path string) ([]Remote, error) {
+	o, err := c.runner.run(ctx, path, "remote", "-v")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseRemotes(o), nil
+}
+
+// parseRemotes parses the output of git remote -v, lines of the form
+// "<name>\t<url> (fetch)". Urls may contain spaces, so only the first tab
+// separates the name and the url runs up to the trailing " (fetch)".
+func parseRemotes(o string) []Remote {
+	rv := make([]Remote, 0)
+	for _, line := range strings.Split(o, "\n") {
+		name, rest, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		if u, ok := strings.CutSuffix(rest, " (fetch)"); ok {
+			rv = append(rv, Remote{name, u})
+		}
+	}
+
+	return rv
+}
+
+// PrimaryRemote picks the remote that identifies the repo: origin if there is
+// one, otherwise the first remote.
+func PrimaryRemote(remotes []Remote) *Remote {
+	for i, r := range remotes {
+		if r.Name == "origin" {
+			return &remotes[i]
+		}
+	}
+
+	if len(remotes) > 0 {
+		return &remotes[0]
+	}
+	return nil
+}
+
+// UpstreamRemote returns the remote a fork tracks, conventionally named
+// upstream, or nil if there is none.
+func UpstreamRemote(remotes []Remote) *Remote {
+	for i, r := range remotes {
+		if r.Name == "upstream" {
+			return &remotes[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package git
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseRemotes(t *testing.T) {
+	o := "origin\tgit@github.com:me/foo.git (fetch)\n" +
+		"origin\tgit@github.com:me/foo.git (push)\n" +
+		"local\t/home/me/My Repos/foo (fetch)\n" +
+		"local\t/home/me/My Repos/foo (push)\n" +
+		"odd\t/tmp/a (fetch) b (fetch)\n" +
+		"\n"
+
+	want := []Remote{
+		{"origin", "git@github.com:me/foo.git"},
+		{"local", "/home/me/My Repos/foo"},
+		{"odd", "/tmp/a (fetch) b"},
+	}
+	if got := parseRemotes(o); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRemotes = %v, want %v", got, want)
+	}
+}
+
+func TestGetRemotesWithSpaces(t *testing.T) {
+	dir, remote := newWipRepo(t)
+	spaced := filepath.Join(t.TempDir(), "with space", "repo.git")
+	if _, err := (runner{}).run(context.Background(), dir, "remote", "add", "backup", spaced); err != nil {
+		t.Fatal(err)
+	}
+
+	remotes, err := New().GetRemotes(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("GetRemotes failed: %v", err)
+	}
+
+	want := []Remote{{"backup", spaced}, {"origin", remote}}
+	if !reflect.DeepEqual(remotes, want) {
+		t.Errorf("GetRemotes = %v, want %v", remotes, want)
+	}
+}
@@ -0,0 +1,90 @@
+package git
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// Status is the parsed output of git status --porcelain=v2, which is stable
+// across git versions and locales unlike the human readable output.
+type Status struct {
+	// Branch is empty when HEAD is detached.
+	Branch, Upstream, Oid string
+	Detached              bool
+
+	Ahead, Behind                           int
+	Staged, Unstaged, Untracked, Conflicted int
+	Stashes                                 int
+}
+
+// Clean reports whether the working tree and index match HEAD, ignoring
+// unpushed commits and stashes.
+func (s Status) Clean() bool {
+	return s.Staged+s.Unstaged+s.Untracked+s.Conflicted == 0
+}
+
+func (c Git) Status(ctx context.Context, path string) (*Status, error) {
+	o, err := c.runner.run(ctx, path, "status", "--porcelain=v2", "--branch", "--show-stash")
+	if err != nil {
+		return nil, err
+	}
+
+	s := parseStatus(o)
+	return &s, nil
+}
+
+func parseStatus(output string) Status {
+	var s Status
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "#":
+			parseHeader(&s, fields[1:])
+		case "1", "2":
+			xy := fields[1]
+			if xy[0] != '.' {
+				s.Staged += 1
+			}
+			if len(xy) > 1 && xy[1] != '.' {
+				s.Unstaged += 1
+			}
+		case "u":
+			s.Conflicted += 1
+		case "?":
+			s.Untracked += 1
+		}
+	}
+
+	return s
+}
+
+func parseHeader(s *Status, fields []string) {
+	if len(fields) < 2 {
+		return
+	}
+
+	switch fields[0] {
+	case "branch.oid":
+		s.Oid = fields[1]
+	case "branch.head":
+		if fields[1] == "(detached)" {
+			s.Detached = true
+		} else {
+			s.Branch = fields[1]
+		}
+	case "branch.upstream":
+		s.Upstream = fields[1]
+	case "branch.ab":
+		if len(fields) > 2 {
+			s.Ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[1], "+"))
+			s.Behind, _ = strconv.Atoi(strings.TrimPrefix(fields[2], "-"))
+		}
+	case "stash":
+		s.Stashes, _ = strconv.Atoi(fields[1])
+	}
+}
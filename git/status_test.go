@@ -0,0 +1,93 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	const oid = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+	tests := []struct {
+		name   string
+		output string
+		want   Status
+	}{
+		{
+			name:   "empty",
+			output: "",
+			want:   Status{},
+		},
+		{
+			name: "clean and up to date",
+			output: "# branch.oid " + oid + "\n" +
+				"# branch.head main\n" +
+				"# branch.upstream origin/main\n" +
+				"# branch.ab +0 -0\n",
+			want: Status{Branch: "main", Upstream: "origin/main", Oid: oid},
+		},
+		{
+			name: "ahead, behind and stashed",
+			output: "# branch.oid " + oid + "\n" +
+				"# branch.head feature/x\n" +
+				"# branch.upstream origin/feature/x\n" +
+				"# branch.ab +3 -12\n" +
+				"# stash 2\n",
+			want: Status{Branch: "feature/x", Upstream: "origin/feature/x", Oid: oid, Ahead: 3, Behind: 12, Stashes: 2},
+		},
+		{
+			name: "no upstream",
+			output: "# branch.oid " + oid + "\n" +
+				"# branch.head local-only\n",
+			want: Status{Branch: "local-only", Oid: oid},
+		},
+		{
+			name: "detached",
+			output: "# branch.oid " + oid + "\n" +
+				"# branch.head (detached)\n",
+			want: Status{Oid: oid, Detached: true},
+		},
+		{
+			name: "initial commit",
+			output: "# branch.oid (initial)\n" +
+				"# branch.head main\n" +
+				"1 A. N... 000000 100644 100644 0000000000000000000000000000000000000000 " + oid + " main.go\n",
+			want: Status{Branch: "main", Oid: "(initial)", Staged: 1},
+		},
+		{
+			name: "entries",
+			output: "# branch.oid " + oid + "\n" +
+				"# branch.head main\n" +
+				"1 .M N... 100644 100644 100644 " + oid + " " + oid + " modified.go\n" +
+				"1 M. N... 100644 100644 100644 " + oid + " " + oid + " staged.go\n" +
+				"1 MM N... 100644 100644 100644 " + oid + " " + oid + " both.go\n" +
+				"1 .D N... 100644 100644 000000 " + oid + " " + oid + " deleted file.go\n" +
+				"2 R. N... 100644 100644 100644 " + oid + " " + oid + " R100 new name.go\told name.go\n" +
+				"u UU N... 100644 100644 100644 100644 " + oid + " " + oid + " " + oid + " conflict.go\n" +
+				"? untracked.go\n" +
+				"? with space.txt\n",
+			want: Status{Branch: "main", Oid: oid, Staged: 3, Unstaged: 3, Conflicted: 1, Untracked: 2},
+		},
+		{
+			name:   "ignored entries and blank lines",
+			output: "# branch.head main\n\n! ignored.log\n",
+			want:   Status{Branch: "main"},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := parseStatus(tt.output); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseStatus = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStatusClean(t *testing.T) {
+	if !(Status{Ahead: 1, Stashes: 1}).Clean() {
+		t.Error("unpushed commits and stashes made the status unclean")
+	}
+	for _, s := range []Status{{Staged: 1}, {Unstaged: 1}, {Untracked: 1}, {Conflicted: 1}} {
+		if s.Clean() {
+			t.Errorf("%+v is clean", s)
+		}
+	}
+}
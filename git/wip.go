@@ -0,0 +1,194 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	DefaultWipMessage     = "wip: {{.Branch}} from {{.Host}} on {{.Date}}"
+	DefaultWipMaxFileSize = 5 << 20
+)
+
+type (
+	WipOptions struct {
+		// MaxFileSize is the largest file in bytes that will be committed,
+		// anything bigger aborts the snapshot.
+		MaxFileSize int64
+		// Message is a text/template for the commit message, executed with
+		// a WipMessageData.
+		Message string
+	}
+
+	WipMessageData struct {
+		Branch, Host, Date string
+	}
+)
+
+// PushLocalChanges snapshots the working tree, including untracked files not
+// covered by .gitignore, into a commit on top of HEAD and pushes it to
+// wip/<hostname>/<branch>/<date>-<time>-<hash> on the primary remote. Each
+// snapshot gets its own branch, an existing one is never overwritten. It goes
+// through a throwaway index so HEAD, the real index and the working tree are
+// left untouched. Returns the pushed branch, or "" if there was nothing to
+// snapshot.
+func (c Git) PushLocalChanges(ctx context.Context, path string, opts WipOptions) (string, error) {
+	status, err := c.Status(ctx, path)
+	if err != nil || status.Clean() {
+		return "", err
+	}
+
+	remotes, err := c.GetRemotes(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	remote := PrimaryRemote(remotes)
+	if remote == nil {
+		return "", fmt.Errorf("%s has no remote to push to", path)
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("os.Hostname failed: %w", err)
+	}
+	host = strings.Split(host, ".")[0]
+
+	now := time.Now()
+	data := WipMessageData{
+		Branch: status.Branch,
+		Host:   host,
+		Date:   now.Format("2006-01-02"),
+	}
+	source := status.Branch
+	if status.Detached {
+		data.Branch, source = "HEAD", "detached"
+	}
+
+	msg, err := wipMessage(opts.Message, data)
+	if err != nil {
+		return "", err
+	}
+
+	commit, err := c.snapshot(ctx, path, status.Oid != "(initial)", msg, opts.MaxFileSize)
+	if err != nil {
+		return "", err
+	}
+
+	// not forced, so a snapshot is never replaced by a later one. The short
+	// hash keeps snapshots taken within the same second apart.
+	branch := fmt.Sprintf("wip/%s/%s/%s-%s", data.Host, source, now.Format("2006-01-02-150405"), Commit{Hash: commit}.ShortHash())
+	refspec := fmt.Sprintf("%s:refs/heads/%s", commit, branch)
+	if _, err := c.runner.run(ctx, path, "push", "--quiet", remote.Name, refspec); err != nil {
+		return "", err
+	}
+
+	return branch, nil
+}
+
+// snapshot commits the working tree via a temporary index and returns the
+// commit's hash, the commit is not referenced by any local branch. Nothing is
+// committed if a file it would add or change is larger than max.
+func (c Git) snapshot(ctx context.Context, path string, hasHead bool, msg string, max int64) (string, error) {
+	f, err := os.CreateTemp("", "sgit-wip-index-")
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	env := []string{"GIT_INDEX_FILE=" + f.Name()}
+	if hasHead {
+		if _, err := c.runner.runWithEnv(ctx, path, env, "read-tree", "HEAD"); err != nil {
+			return "", err
+		}
+	} else {
+		// git refuses to use an existing empty file as an index
+		os.Remove(f.Name())
+	}
+
+	if _, err := c.runner.runWithEnv(ctx, path, env, "add", "--all"); err != nil {
+		return "", err
+	}
+
+	if err := c.checkFileSizes(ctx, path, env, hasHead, max); err != nil {
+		return "", err
+	}
+
+	tree, err := c.runner.runWithEnv(ctx, path, env, "write-tree")
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"commit-tree", strings.TrimSpace(tree), "-m", msg}
+	if hasHead {
+		args = append(args, "-p", "HEAD")
+	}
+
+	commit, err := c.runner.run(ctx, path, args...)
+	return strings.TrimSpace(commit), err
+}
+
+// checkFileSizes refuses to snapshot files larger than max, which are far
+// more likely to be build output or data than work. It checks everything the
+// index in env adds or changes relative to HEAD, so files staged with git add
+// are covered as well as modified and untracked ones.
+func (c Git) checkFileSizes(ctx context.Context, path string, env []string, hasHead bool, max int64) error {
+	if max <= 0 {
+		return nil
+	}
+
+	args := []string{"ls-files", "-z"}
+	if hasHead {
+		args = []string{"diff", "--cached", "--name-only", "-z", "--no-renames", "--diff-filter=d", "HEAD"}
+	}
+
+	o, err := c.runner.runWithEnv(ctx, path, env, args...)
+	if err != nil {
+		return err
+	}
+
+	tooLarge := make([]string, 0)
+	for _, f := range strings.Split(o, "\x00") {
+		if f == "" {
+			continue
+		}
+
+		info, err := os.Stat(filepath.Join(path, f))
+		if err == nil && info.Size() > max {
+			tooLarge = append(tooLarge, fmt.Sprintf("%s (%d bytes)", f, info.Size()))
+		}
+	}
+
+	if len(tooLarge) > 0 {
+		return fmt.Errorf(
+			"refusing to push files larger than %d bytes: %s",
+			max,
+			strings.Join(tooLarge, ", "),
+		)
+	}
+
+	return nil
+}
+
+func wipMessage(tmpl string, data WipMessageData) (string, error) {
+	if tmpl == "" {
+		tmpl = DefaultWipMessage
+	}
+
+	t, err := template.New("wip").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid wip message template: %w", err)
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("invalid wip message template: %w", err)
+	}
+	return b.String(), nil
+}
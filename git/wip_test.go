@@ -0,0 +1,117 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// newWipRepo returns a clone with one commit whose origin is a bare repo in
+// a temporary directory.
+func newWipRepo(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := binary(); err != nil {
+		t.Skip("git not installed")
+	}
+
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	ctx := context.Background()
+	r := runner{}
+	remote, dir := filepath.Join(t.TempDir(), "remote.git"), t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet", "--bare", remote},
+		{"init", "--quiet", "--initial-branch=main", dir},
+		{"-C", dir, "remote", "add", "origin", remote},
+	} {
+		if _, err := r.run(ctx, "", args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile(t, dir, "main.go", "package main\n")
+	for _, args := range [][]string{{"add", "main.go"}, {"commit", "--quiet", "-m", "initial"}} {
+		if _, err := r.run(ctx, dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir, remote
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func remoteBranches(t *testing.T, remote string) []string {
+	t.Helper()
+	o, err := runner{}.run(context.Background(), remote, "for-each-ref", "--format=%(refname:short)", "refs/heads/wip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Fields(o)
+}
+
+func TestPushLocalChangesRefusesStagedLargeFiles(t *testing.T) {
+	dir, remote := newWipRepo(t)
+	ctx := context.Background()
+
+	// staged and unchanged since, so ls-files --modified doesn't list it
+	writeFile(t, dir, "big.bin", strings.Repeat("x", 2048))
+	if _, err := (runner{}).run(ctx, dir, "add", "big.bin"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := New().PushLocalChanges(ctx, dir, WipOptions{MaxFileSize: 1024})
+	if err == nil || !strings.Contains(err.Error(), "big.bin") {
+		t.Fatalf("PushLocalChanges returned %v, want big.bin refused", err)
+	}
+	if b := remoteBranches(t, remote); len(b) != 0 {
+		t.Errorf("pushed %v despite the refusal", b)
+	}
+}
+
+func TestPushLocalChangesKeepsEarlierSnapshots(t *testing.T) {
+	dir, remote := newWipRepo(t)
+	ctx := context.Background()
+	g := New()
+
+	writeFile(t, dir, "a.go", "package main\n")
+	first, err := g.PushLocalChanges(ctx, dir, WipOptions{})
+	if err != nil {
+		t.Fatalf("first PushLocalChanges failed: %v", err)
+	}
+	if !strings.Contains(first, "/main/") {
+		t.Errorf("branch %s doesn't name the source branch", first)
+	}
+
+	writeFile(t, dir, "b.go", "package main\n")
+	second, err := g.PushLocalChanges(ctx, dir, WipOptions{})
+	if err != nil {
+		t.Fatalf("second PushLocalChanges failed: %v", err)
+	}
+
+	if first == second {
+		t.Fatalf("both snapshots pushed to %s", first)
+	}
+	if !regexp.MustCompile(`^wip/[^/]+/main/\d{4}-\d{2}-\d{2}-\d{6}-[0-9a-f]{7}$`).MatchString(second) {
+		t.Errorf("branch %s isn't wip/<host>/main/<date>-<time>-<hash>", second)
+	}
+	if b := remoteBranches(t, remote); len(b) != 2 {
+		t.Errorf("remote has wip branches %v, want %s and %s", b, first, second)
+	}
+
+	status, err := g.Status(ctx, dir)
+	if err != nil || status.Clean() {
+		t.Errorf("working tree changed by the snapshot: %v", err)
+	}
+}
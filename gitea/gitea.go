@@ -0,0 +1,207 @@
+package gitea
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sgit/provider"
+	"strings"
+	"time"
+)
+
+// perPage is Gitea's default MAX_RESPONSE_ITEMS. Servers silently cap limit
+// at their own MAX_RESPONSE_ITEMS, which may be lower, so a short page doesn't
+// mean it was the last one.
+const perPage = 50
+
+type (
+	Repository struct {
+		Name     string `json:"name"`
+		SshUrl   string `json:"ssh_url"`
+		Fork     bool   `json:"fork"`
+		Language string `json:"language"`
+		Owner    *Owner `json:"owner"`
+		Size     int64  `json:"size"`
+		// UpdatedAt is the closest Gitea has to a last push time.
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+
+	Owner struct {
+		Login string `json:"login"`
+	}
+
+	// Gitea talks to the API of a Gitea or Forgejo instance, which share the
+	// same v1 REST API.
+	Gitea struct {
+		token, baseUrl string
+	}
+)
+
+// New returns a client for the instance at baseUrl, e.g.
+// https://gitea.example.com.
+func New(token, baseUrl string) (*Gitea, error) {
+	if baseUrl == "" {
+		return nil, errors.New("gitea url is not set")
+	}
+
+	u, err := url.Parse(baseUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gitea url %q: %w", baseUrl, err)
+	} else if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid gitea url %q, expected e.g. https://gitea.example.com", baseUrl)
+	}
+
+	return &Gitea{token, strings.TrimSuffix(baseUrl, "/")}, nil
+}
+
+func (g Gitea) Host() string {
+	u, err := url.Parse(g.baseUrl)
+	if err != nil {
+		return g.baseUrl
+	}
+	return u.Host
+}
+
+func (g Gitea) GetUsername(ctx context.Context) (string, error) {
+	user, err := execute[Owner](ctx, g, http.MethodGet, "/user", nil)
+	if err != nil {
+		return "", err
+	}
+	return user.Login, nil
+}
+
+// GetAllRepos pages through the user's repos until an empty page, see
+// perPage.
+func (g Gitea) GetAllRepos(ctx context.Context) ([]provider.Repository, error) {
+	rv := make([]provider.Repository, 0)
+	for page := 1; ; page++ {
+		e := fmt.Sprintf("/user/repos?limit=%d&page=%d", perPage, page)
+		repos, err := execute[[]Repository](ctx, g, http.MethodGet, e, nil)
+		if err != nil {
+			return rv, fmt.Errorf("page %d: %w", page, err)
+		}
+
+		if len(*repos) == 0 {
+			return rv, nil
+		}
+
+		for _, r := range *repos {
+			rv = append(rv, r.toProvider())
+		}
+	}
+}
+
+func (g Gitea) GetPrimaryLanguageForRepo(ctx context.Context, owner, name string) (string, error) {
+	e := fmt.Sprintf("/repos/%s/%s/languages", owner, name)
+	langs, err := execute[map[string]int64](ctx, g, http.MethodGet, e, nil)
+	if err != nil {
+		return "", err
+	}
+
+	primaryLanguage := "unknown"
+	maxByteCount := int64(-1)
+	for k, v := range *langs {
+		if v > maxByteCount {
+			primaryLanguage = k
+			maxByteCount = v
+		}
+	}
+	return primaryLanguage, nil
+}
+
+func (g Gitea) CreateRepo(ctx context.Context, name string, private bool) (*provider.Repository, error) {
+	json := struct {
+		Name    string `json:"name"`
+		Private bool   `json:"private"`
+	}{name, private}
+
+	repo, err := execute[Repository](ctx, g, http.MethodPost, "/user/repos", json)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := repo.toProvider()
+	return &rv, nil
+}
+
+func (g Gitea) DeleteRepo(ctx context.Context, owner, name string) error {
+	e := fmt.Sprintf("/repos/%s/%s", owner, name)
+	_, err := execute[struct{}](ctx, g, http.MethodDelete, e, nil)
+	return err
+}
+
+// toProvider leaves an empty language as is so callers fall back to the
+// languages endpoint, Gitea only fills it in once its indexer has run.
+func (r Repository) toProvider() provider.Repository {
+	rv := provider.Repository{
+		Name:     r.Name,
+		SshUrl:   r.SshUrl,
+		Language: r.Language,
+		Fork:     r.Fork,
+		PushedAt: r.UpdatedAt,
+		Size:     r.Size,
+	}
+
+	if r.Owner != nil {
+		rv.Owner = r.Owner.Login
+	}
+
+	return rv
+}
+
+func execute[T any](ctx context.Context, g Gitea, verb, endpoint string, body any) (*T, error) {
+	url := g.baseUrl + "/api/v1" + endpoint
+
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("json.Marshal failed: %w", err)
+		}
+		r = bytes.NewBuffer(b)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, verb, url, r)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Authorization", "token "+g.token)
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	client := &http.Client{}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		msg := fmt.Sprintf("%s: %s", res.Status, resBody)
+		return nil, errors.New(msg)
+	}
+
+	if len(resBody) == 0 {
+		return new(T), nil
+	}
+
+	// a null body leaves t at its zero value rather than nil
+	t := new(T)
+	err = json.Unmarshal(resBody, t)
+	return t, err
+}
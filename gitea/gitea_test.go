@@ -0,0 +1,272 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testToken = "secret"
+
+// fakeServer is an in-memory Gitea serving the endpoints Gitea uses.
+type fakeServer struct {
+	mu    sync.Mutex
+	repos []Repository
+	langs map[string]map[string]int64
+	pages []int
+	// failPage is a page of /user/repos that errors, 0 for none
+	failPage int
+	// maxItems caps limit as MAX_RESPONSE_ITEMS does, 0 for no cap
+	maxItems int
+}
+
+func newFakeServer(t *testing.T, repos int) (*fakeServer, *Gitea) {
+	t.Helper()
+
+	f := &fakeServer{langs: make(map[string]map[string]int64, 0)}
+	for n := 0; n < repos; n++ {
+		f.repos = append(f.repos, Repository{
+			Name:   fmt.Sprintf("repo-%03d", n),
+			SshUrl: fmt.Sprintf("git@gitea.test:me/repo-%03d.git", n),
+			Owner:  &Owner{"me"},
+		})
+	}
+
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	g, err := New(testToken, srv.URL+"/")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return f, g
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "token "+testToken {
+		http.Error(w, `{"message":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1")
+	p := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case r.Method == http.MethodGet && path == "/user":
+		json.NewEncoder(w).Encode(Owner{"me"})
+	case r.Method == http.MethodGet && path == "/user/repos":
+		f.listRepos(w, r)
+	case r.Method == http.MethodPost && path == "/user/repos":
+		f.createRepo(w, r)
+	case r.Method == http.MethodGet && len(p) == 4 && p[0] == "repos" && p[3] == "languages":
+		json.NewEncoder(w).Encode(f.langs[p[1]+"/"+p[2]])
+	case r.Method == http.MethodDelete && len(p) == 3 && p[0] == "repos":
+		f.deleteRepo(w, p[1], p[2])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeServer) listRepos(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if limit <= 0 || page <= 0 {
+		http.Error(w, "bad paging", http.StatusBadRequest)
+		return
+	}
+	if f.maxItems > 0 && limit > f.maxItems {
+		limit = f.maxItems
+	}
+	f.pages = append(f.pages, page)
+	if page == f.failPage {
+		http.Error(w, `{"message":"boom"}`, http.StatusInternalServerError)
+		return
+	}
+
+	start, end := (page-1)*limit, page*limit
+	if start > len(f.repos) {
+		start = len(f.repos)
+	}
+	if end > len(f.repos) {
+		end = len(f.repos)
+	}
+	json.NewEncoder(w).Encode(f.repos[start:end])
+}
+
+func (f *fakeServer) createRepo(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name    string `json:"name"`
+		Private bool   `json:"private"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" {
+		http.Error(w, "bad body", http.StatusUnprocessableEntity)
+		return
+	}
+
+	for _, repo := range f.repos {
+		if repo.Name == body.Name {
+			http.Error(w, `{"message":"repo already exists"}`, http.StatusConflict)
+			return
+		}
+	}
+
+	repo := Repository{Name: body.Name, SshUrl: "git@gitea.test:me/" + body.Name + ".git", Owner: &Owner{"me"}}
+	f.repos = append(f.repos, repo)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(repo)
+}
+
+func (f *fakeServer) deleteRepo(w http.ResponseWriter, owner, name string) {
+	for idx, repo := range f.repos {
+		if repo.Owner.Login == owner && repo.Name == name {
+			f.repos = append(f.repos[:idx], f.repos[idx+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	http.NotFound(w, nil)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		url     string
+		host    string
+		wantErr bool
+	}{
+		{"https://gitea.example.com", "gitea.example.com", false},
+		{"https://gitea.example.com:3000/", "gitea.example.com:3000", false},
+		{"", "", true},
+		{"gitea.example.com", "", true},
+		{"://bad", "", true},
+	}
+
+	for _, tt := range tests {
+		g, err := New("token", tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("New(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if err == nil && g.Host() != tt.host {
+			t.Errorf("New(%q).Host() = %q, want %q", tt.url, g.Host(), tt.host)
+		}
+	}
+}
+
+func TestGetAllReposPaginates(t *testing.T) {
+	tests := []struct {
+		repos    int
+		maxItems int
+		pages    []int
+	}{
+		{0, 0, []int{1}},
+		{perPage - 1, 0, []int{1, 2}},
+		{perPage, 0, []int{1, 2}},
+		{2*perPage + 3, 0, []int{1, 2, 3, 4}},
+		// a server capping pages below perPage still has every repo read
+		{25, 10, []int{1, 2, 3, 4}},
+	}
+
+	for _, tt := range tests {
+		f, g := newFakeServer(t, tt.repos)
+		f.maxItems = tt.maxItems
+		repos, err := g.GetAllRepos(context.Background())
+		if err != nil {
+			t.Fatalf("%d repos: GetAllRepos failed: %v", tt.repos, err)
+		}
+
+		if len(repos) != tt.repos {
+			t.Errorf("%d repos: got %d", tt.repos, len(repos))
+		}
+		if fmt.Sprint(f.pages) != fmt.Sprint(tt.pages) {
+			t.Errorf("%d repos: requested pages %v, want %v", tt.repos, f.pages, tt.pages)
+		}
+
+		seen := make(map[string]struct{}, 0)
+		for _, r := range repos {
+			if r.Owner != "me" {
+				t.Errorf("%s: owner = %q, want me", r.Name, r.Owner)
+			}
+			seen[r.Name] = struct{}{}
+		}
+		if len(seen) != tt.repos {
+			t.Errorf("%d repos: got %d distinct", tt.repos, len(seen))
+		}
+	}
+}
+
+func TestGetAllReposReturnsPartialPages(t *testing.T) {
+	f, g := newFakeServer(t, 2*perPage+3)
+	f.failPage = 2
+
+	repos, err := g.GetAllRepos(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "page 2") {
+		t.Errorf("GetAllRepos returned %v, want a page 2 error", err)
+	}
+	if len(repos) != perPage {
+		t.Errorf("got %d repos, want the %d from page 1", len(repos), perPage)
+	}
+}
+
+func TestGetPrimaryLanguageForRepo(t *testing.T) {
+	f, g := newFakeServer(t, 1)
+	f.langs["me/repo-000"] = map[string]int64{"Go": 900, "Shell": 100}
+
+	lang, err := g.GetPrimaryLanguageForRepo(context.Background(), "me", "repo-000")
+	if err != nil {
+		t.Fatalf("GetPrimaryLanguageForRepo failed: %v", err)
+	} else if lang != "Go" {
+		t.Errorf("got %q, want Go", lang)
+	}
+
+	lang, err = g.GetPrimaryLanguageForRepo(context.Background(), "me", "empty")
+	if err != nil {
+		t.Fatalf("GetPrimaryLanguageForRepo failed: %v", err)
+	} else if lang != "unknown" {
+		t.Errorf("got %q for a repo without languages, want unknown", lang)
+	}
+}
+
+func TestCreateAndDeleteRepo(t *testing.T) {
+	f, g := newFakeServer(t, 0)
+	ctx := context.Background()
+
+	repo, err := g.CreateRepo(ctx, "new", true)
+	if err != nil {
+		t.Fatalf("CreateRepo failed: %v", err)
+	}
+	if repo.Name != "new" || repo.Owner != "me" || repo.SshUrl != "git@gitea.test:me/new.git" {
+		t.Errorf("CreateRepo returned %+v", repo)
+	}
+
+	if _, err := g.CreateRepo(ctx, "new", true); err == nil {
+		t.Error("creating an existing repo succeeded")
+	}
+
+	if err := g.DeleteRepo(ctx, "me", "new"); err != nil {
+		t.Fatalf("DeleteRepo failed: %v", err)
+	}
+	if len(f.repos) != 0 {
+		t.Errorf("%d repos left after delete", len(f.repos))
+	}
+
+	if err := g.DeleteRepo(ctx, "me", "new"); err == nil {
+		t.Error("deleting a missing repo succeeded")
+	}
+}
+
+func TestUnauthorized(t *testing.T) {
+	_, g := newFakeServer(t, 1)
+	g.token = "wrong"
+
+	if _, err := g.GetUsername(context.Background()); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("GetUsername with a bad token returned %v, want a 401 error", err)
+	}
+}
@@ -8,15 +8,38 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"sgit/provider"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
+const (
+	DefaultHost = "github.com"
+
+	defaultApiUrl = "https://api.github.com"
+
+	perPage              = 100
+	maxConcurrentFetches = 4
+
+	AffiliationOwner              = "owner"
+	AffiliationCollaborator       = "collaborator"
+	AffiliationOrganizationMember = "organization_member"
+)
+
 type (
 	Repository struct {
-		FullName string `json:"full_name"`
-		SshUrl   string `json:"ssh_url"`
-		Fork     bool   `json:"fork"`
-		Owner    *Owner `json:"owner"`
+		FullName string    `json:"full_name"`
+		SshUrl   string    `json:"ssh_url"`
+		Fork     bool      `json:"fork"`
+		Owner    *Owner    `json:"owner"`
+		PushedAt time.Time `json:"pushed_at"`
+		Size     int64     `json:"size"`
+
+		// Language is only populated by GetAllReposWithLanguages.
+		Language string `json:"-"`
 	}
 
 	Owner struct {
@@ -25,16 +48,98 @@ type (
 
 	Github struct {
 		token, username string
+		apiUrl, host    string
+		budget          time.Duration
+		affiliations    []string
+		orgs            []string
 	}
 )
 
 func New(token, username string) *Github {
-	return &Github{token, username}
+	return &Github{
+		token:        token,
+		username:     username,
+		apiUrl:       defaultApiUrl,
+		host:         DefaultHost,
+		budget:       defaultRequestBudget,
+		affiliations: []string{AffiliationOwner},
+	}
+}
+
+// SetScope selects which repositories GetAllRepos and GetAllReposWithLanguages
+// return: those matching any of the affiliations, plus every repository of
+// the given orgs.
+func (g *Github) SetScope(affiliations, orgs []string) error {
+	for _, a := range affiliations {
+		switch a {
+		case AffiliationOwner, AffiliationCollaborator, AffiliationOrganizationMember:
+		default:
+			return fmt.Errorf(
+				"invalid affiliation \"%s\", valid affiliations: %s %s %s",
+				a,
+				AffiliationOwner,
+				AffiliationCollaborator,
+				AffiliationOrganizationMember,
+			)
+		}
+	}
+
+	g.affiliations = affiliations
+	g.orgs = orgs
+	return nil
+}
+
+// SetEndpoints points the client at a GitHub Enterprise Server. apiUrl may be
+// given with or without the /api/v3 suffix; host is the web and ssh host and
+// defaults to the api url's host.
+func (g *Github) SetEndpoints(apiUrl, host string) error {
+	if apiUrl != "" {
+		u, err := url.Parse(strings.TrimSuffix(apiUrl, "/"))
+		if err != nil {
+			return fmt.Errorf("invalid api url %s: %w", apiUrl, err)
+		}
+
+		if u.Host != "api.github.com" && !strings.HasSuffix(u.Path, "/api/v3") {
+			u.Path += "/api/v3"
+		}
+		g.apiUrl = u.String()
+
+		if host == "" {
+			host = strings.TrimPrefix(u.Host, "api.")
+		}
+	}
+
+	if host != "" {
+		g.host = host
+	}
+
+	return nil
+}
+
+// Host returns the web and ssh host repos are served from.
+func (g Github) Host() string {
+	return g.host
+}
+
+// SetRequestBudget caps the total time a single API call may spend across
+// retries, including time spent waiting out rate limits.
+func (g *Github) SetRequestBudget(budget time.Duration) {
+	if budget > 0 {
+		g.budget = budget
+	}
+}
+
+func (g Github) GetUsername(ctx context.Context) (string, error) {
+	user, err := execute[Owner](ctx, g, http.MethodGet, "/user", nil, true)
+	if err != nil {
+		return "", err
+	}
+	return user.Login, nil
 }
 
 func (g Github) GetPrimaryLanguageForRepo(ctx context.Context, owner, name string) (string, error) {
 	e := fmt.Sprintf("/repos/%s/%s/languages", owner, name)
-	langs, err := execute[map[string]int](ctx, http.MethodGet, e, g.token, nil)
+	langs, err := execute[map[string]int](ctx, g, http.MethodGet, e, nil, true)
 	if err != nil {
 		return "", err
 	}
@@ -50,47 +155,304 @@ func (g Github) GetPrimaryLanguageForRepo(ctx context.Context, owner, name strin
 	return primaryLanguage, nil
 }
 
-func (g Github) GetAllRepos(ctx context.Context) ([]Repository, error) {
-	// TODO: update to paginate correctly
-	e := "/user/repos?affiliation=owner&per_page=100"
-	repos, err := execute[[]Repository](ctx, http.MethodGet, e, g.token, nil)
+// GetAllRepos lists the repositories in scope (see SetScope), using GraphQL
+// so languages come back in the same request and falling back to REST
+// without languages if that fails. If REST pages fail too, the repos from the
+// pages that succeeded are returned along with the errors.
+func (g Github) GetAllRepos(ctx context.Context) ([]provider.Repository, error) {
+	repos, err := g.GetAllReposWithLanguages(ctx)
 	if err != nil {
-		return nil, err
+		var restErr error
+		repos, restErr = g.ListRepos(ctx)
+		if restErr != nil {
+			err = errors.Join(err, restErr)
+		} else {
+			err = nil
+		}
+	}
+
+	rv := make([]provider.Repository, 0)
+	for _, r := range repos {
+		rv = append(rv, r.toProvider())
+	}
+	return rv, err
+}
+
+// ListRepos fetches every page of the repositories in scope (see SetScope)
+// from the REST API. Pages that fail are reported in the returned error
+// alongside the repos from the pages that succeeded.
+func (g Github) ListRepos(ctx context.Context) ([]Repository, error) {
+	endpoints := make([]string, 0)
+	if len(g.affiliations) > 0 {
+		endpoints = append(endpoints, fmt.Sprintf(
+			"/user/repos?affiliation=%s&per_page=%d",
+			strings.Join(g.affiliations, ","),
+			perPage,
+		))
+	}
+
+	for _, org := range g.orgs {
+		endpoints = append(endpoints, fmt.Sprintf("/orgs/%s/repos?per_page=%d", org, perPage))
+	}
+
+	rv := make([]Repository, 0)
+	seen := make(map[string]struct{}, 0)
+	errs := make([]error, 0)
+	for _, e := range endpoints {
+		repos, err := getAllPages[Repository](ctx, g, e)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e, err))
+		}
+
+		for _, r := range repos {
+			if _, ok := seen[r.FullName]; !ok {
+				seen[r.FullName] = struct{}{}
+				rv = append(rv, r)
+			}
+		}
 	}
 
-	return *repos, nil
+	return rv, errors.Join(errs...)
+}
+
+func getAllPages[T any](ctx context.Context, g Github, endpoint string) ([]T, error) {
+	first, header, err := executeWithHeader[[]T](ctx, g, http.MethodGet, withPage(endpoint, 1), nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("page 1: %w", err)
+	}
+
+	rv := *first
+	links := parseLinkHeader(header.Get("Link"))
+	if _, ok := links["next"]; !ok {
+		return rv, nil
+	}
+
+	last, err := pageNumber(links["last"])
+	if err != nil {
+		return getPagesSequentially(ctx, g, rv, links["next"])
+	}
+
+	type result struct {
+		page  int
+		items []T
+		err   error
+	}
+
+	results := make(chan result, last-1)
+	sem := make(chan struct{}, maxConcurrentFetches)
+	var wg sync.WaitGroup
+	for page := 2; page <= last; page++ {
+		wg.Add(1)
+		go func(page int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			items, _, err := executeWithHeader[[]T](ctx, g, http.MethodGet, withPage(endpoint, page), nil, true)
+			if err != nil {
+				results <- result{page, nil, fmt.Errorf("page %d: %w", page, err)}
+				return
+			}
+			results <- result{page, *items, nil}
+		}(page)
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pages := make([][]T, last+1)
+	errs := make([]error, 0)
+	for r := range results {
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+		pages[r.page] = r.items
+	}
+
+	for _, items := range pages {
+		rv = append(rv, items...)
+	}
+
+	return rv, errors.Join(errs...)
+}
+
+// getPagesSequentially follows rel="next" links one at a time, used when the
+// API omits rel="last" and the page count is not known up front.
+func getPagesSequentially[T any](ctx context.Context, g Github, rv []T, next string) ([]T, error) {
+	for next != "" {
+		items, header, err := executeWithHeader[[]T](ctx, g, http.MethodGet, next, nil, true)
+		if err != nil {
+			return rv, fmt.Errorf("%s: %w", next, err)
+		}
+
+		rv = append(rv, *items...)
+		next = parseLinkHeader(header.Get("Link"))["next"]
+	}
+
+	return rv, nil
+}
+
+func withPage(endpoint string, page int) string {
+	sep := "?"
+	if strings.Contains(endpoint, "?") {
+		sep = "&"
+	}
+	return fmt.Sprintf("%s%spage=%d", endpoint, sep, page)
+}
+
+// parseLinkHeader maps each rel of an RFC 8288 Link header to its url, e.g.
+// <https://api.github.com/user/repos?page=2>; rel="next"
+func parseLinkHeader(header string) map[string]string {
+	rv := make(map[string]string, 0)
+	for _, link := range strings.Split(header, ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 {
+			continue
+		}
+
+		u := strings.Trim(strings.TrimSpace(parts[0]), "<>")
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "rel=") {
+				rel := strings.Trim(strings.TrimPrefix(param, "rel="), "\"")
+				rv[rel] = u
+			}
+		}
+	}
+	return rv
+}
+
+func pageNumber(link string) (int, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(u.Query().Get("page"))
 }
 
 func (g Github) DeleteRepo(ctx context.Context, owner, name string) error {
 	e := fmt.Sprintf("/repos/%s/%s", owner, name)
-	_, err := execute[struct{}](ctx, http.MethodDelete, e, g.token, nil)
+	_, err := execute[struct{}](ctx, g, http.MethodDelete, e, nil, false)
 	return err
 }
 
-func (g Github) CreateRepo(ctx context.Context, name string, private bool) error {
+func (g Github) CreateRepo(ctx context.Context, name string, private bool) (*provider.Repository, error) {
 	e := "/user/repos"
 	json := struct {
 		Name    string `json:"name"`
 		Private bool   `json:"private"`
 	}{name, private}
 
-	_, err := execute[struct{}](ctx, http.MethodPost, e, g.token, json)
-	return err
+	repo, err := execute[Repository](ctx, g, http.MethodPost, e, json, false)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := repo.toProvider()
+	return &rv, nil
 }
 
-func execute[T any](ctx context.Context, verb, endpoint, token string, body any) (*T, error) {
-	url := "https://api.github.com" + endpoint
+func (r Repository) toProvider() provider.Repository {
+	p := strings.Split(r.FullName, "/")
+	rv := provider.Repository{
+		Name:     p[len(p)-1],
+		SshUrl:   r.SshUrl,
+		Language: r.Language,
+		Fork:     r.Fork,
+		PushedAt: r.PushedAt,
+		Size:     r.Size,
+	}
 
-	var r io.Reader
+	if r.Owner != nil {
+		rv.Owner = r.Owner.Login
+	}
+
+	return rv
+}
+
+func execute[T any](ctx context.Context, g Github, verb, endpoint string, body any, idempotent bool) (*T, error) {
+	t, _, err := executeWithHeader[T](ctx, g, verb, endpoint, body, idempotent)
+	return t, err
+}
+
+// executeWithHeader performs a request, retrying failures with backoff until
+// g.budget is spent, see retryDelay for which. idempotent marks requests that
+// are safe to repeat, whatever their verb.
+func executeWithHeader[T any](ctx context.Context, g Github, verb, endpoint string, body any, idempotent bool) (*T, http.Header, error) {
+	var payload []byte
 	if body != nil {
 		b, err := json.Marshal(body)
 		if err != nil {
-			return nil, fmt.Errorf("json.Marshal failed: %w", err)
+			return nil, nil, fmt.Errorf("json.Marshal failed: %w", err)
+		}
+		payload = b
+	}
+
+	deadline := time.Now().Add(g.budget)
+	for attempt := 0; ; attempt++ {
+		res, err := do(ctx, verb, g.url(endpoint), g.token, payload)
+		if err == nil && res.StatusCode >= 200 && res.StatusCode <= 299 {
+			var t *T
+			if len(res.body) == 0 {
+				return new(T), res.Header, nil
+			}
+			err = json.Unmarshal(res.body, &t)
+			return t, res.Header, err
+		}
+
+		if err == nil {
+			err = fmt.Errorf("%s: %s", res.Status, res.body)
+		} else if ctx.Err() != nil {
+			return nil, nil, err
+		}
+
+		wait, retry, rlErr := retryDelay(idempotent, res, attempt)
+		if rlErr != nil {
+			err = rlErr
+		}
+
+		if !retry || time.Now().Add(wait).After(deadline) {
+			return nil, nil, err
 		}
-		r = bytes.NewBuffer(b)
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// url resolves endpoint against the REST api, absolute urls such as those
+// from Link headers are used as is.
+func (g Github) url(endpoint string) string {
+	if strings.HasPrefix(endpoint, "https://") || strings.HasPrefix(endpoint, "http://") {
+		return endpoint
+	}
+	return g.apiUrl + endpoint
+}
+
+// graphqlUrl is api.github.com/graphql on github.com but /api/graphql rather
+// than /api/v3/graphql on Enterprise Server.
+func (g Github) graphqlUrl() string {
+	return strings.TrimSuffix(g.apiUrl, "/v3") + "/graphql"
+}
+
+type response struct {
+	*http.Response
+	body []byte
+}
+
+func do(ctx context.Context, verb, url, token string, payload []byte) (*response, error) {
+	var r io.Reader
+	if payload != nil {
+		r = bytes.NewReader(payload)
 	}
 
-	reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	reqCtx, cancel := context.WithTimeout(ctx, requestTimeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(reqCtx, verb, url, r)
@@ -112,12 +474,5 @@ func execute[T any](ctx context.Context, verb, endpoint, token string, body any)
 		return nil, err
 	}
 
-	if res.StatusCode < 200 || res.StatusCode > 299 {
-		msg := fmt.Sprintf("%s: %s", res.Status, body)
-		return nil, errors.New(msg)
-	}
-
-	var t *T
-	err = json.Unmarshal(resBody, &t)
-	return t, err
+	return &response{res, resBody}, nil
 }
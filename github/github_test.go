@@ -0,0 +1,169 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pagedServer serves items 0..total-1 from /api/v3/items, perPage at a time,
+// linking pages the way GitHub does.
+type pagedServer struct {
+	total, perPage int
+	// noLast leaves rel="last" out of the Link header
+	noLast bool
+	// failPage responds with a 404 for that page, 0 for none
+	failPage int
+
+	mu       sync.Mutex
+	pages    []int
+	inFlight int32
+	maxSeen  int32
+}
+
+func (s *pagedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	s.mu.Lock()
+	s.pages = append(s.pages, page)
+	s.mu.Unlock()
+
+	n := atomic.AddInt32(&s.inFlight, 1)
+	defer atomic.AddInt32(&s.inFlight, -1)
+	for {
+		max := atomic.LoadInt32(&s.maxSeen)
+		if n <= max || atomic.CompareAndSwapInt32(&s.maxSeen, max, n) {
+			break
+		}
+	}
+
+	if page == s.failPage {
+		http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+		return
+	}
+
+	// earlier pages answer last, so results arrive out of order
+	last := (s.total + s.perPage - 1) / s.perPage
+	time.Sleep(time.Duration(last-page) * 20 * time.Millisecond)
+
+	link := func(p int, rel string) string {
+		return fmt.Sprintf(`<http://%s/api/v3/items?per_page=%d&page=%d>; rel="%s"`, r.Host, s.perPage, p, rel)
+	}
+	links := make([]string, 0)
+	if page < last {
+		links = append(links, link(page+1, "next"))
+		if !s.noLast {
+			links = append(links, link(last, "last"))
+		}
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	items := make([]int, 0)
+	for i := (page - 1) * s.perPage; i < page*s.perPage && i < s.total; i++ {
+		items = append(items, i)
+	}
+	json.NewEncoder(w).Encode(items)
+}
+
+func newPagedServer(t *testing.T, s *pagedServer) Github {
+	t.Helper()
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+
+	g := New("token", "me")
+	if err := g.SetEndpoints(srv.URL, "github.test"); err != nil {
+		t.Fatal(err)
+	}
+	return *g
+}
+
+func sequence(from, to int) []int {
+	rv := make([]int, 0)
+	for i := from; i < to; i++ {
+		rv = append(rv, i)
+	}
+	return rv
+}
+
+func TestGetAllPagesConcurrently(t *testing.T) {
+	s := &pagedServer{total: 10, perPage: 3}
+	g := newPagedServer(t, s)
+
+	items, err := getAllPages[int](context.Background(), g, "/items?per_page=3")
+	if err != nil {
+		t.Fatalf("getAllPages failed: %v", err)
+	}
+	if want := sequence(0, 10); !reflect.DeepEqual(items, want) {
+		t.Errorf("getAllPages = %v, want %v in order", items, want)
+	}
+	if len(s.pages) != 4 {
+		t.Errorf("requested pages %v, want each of the 4 once", s.pages)
+	}
+	if s.maxSeen < 2 {
+		t.Errorf("at most %d requests were in flight, want the pages after the first fetched concurrently", s.maxSeen)
+	}
+}
+
+func TestGetAllPagesWithoutLast(t *testing.T) {
+	s := &pagedServer{total: 10, perPage: 3, noLast: true}
+	g := newPagedServer(t, s)
+
+	items, err := getAllPages[int](context.Background(), g, "/items?per_page=3")
+	if err != nil {
+		t.Fatalf("getAllPages failed: %v", err)
+	}
+	if want := sequence(0, 10); !reflect.DeepEqual(items, want) {
+		t.Errorf("getAllPages = %v, want %v", items, want)
+	}
+	if want := []int{1, 2, 3, 4}; !reflect.DeepEqual(s.pages, want) {
+		t.Errorf("requested pages %v, want %v", s.pages, want)
+	}
+	if s.maxSeen != 1 {
+		t.Errorf("%d requests were in flight, want next links followed one at a time", s.maxSeen)
+	}
+}
+
+func TestGetAllPagesReturnsPartialPages(t *testing.T) {
+	s := &pagedServer{total: 10, perPage: 3, failPage: 2}
+	g := newPagedServer(t, s)
+
+	items, err := getAllPages[int](context.Background(), g, "/items?per_page=3")
+	if err == nil || !strings.Contains(err.Error(), "page 2") {
+		t.Errorf("getAllPages returned %v, want a page 2 error", err)
+	}
+
+	want := append(sequence(0, 3), sequence(6, 10)...)
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("getAllPages = %v, want the other pages %v", items, want)
+	}
+}
+
+func TestParseLinkHeader(t *testing.T) {
+	header := `<https://api.github.com/user/repos?page=2>; rel="next", ` +
+		`<https://api.github.com/user/repos?page=5>; rel="last"`
+	want := map[string]string{
+		"next": "https://api.github.com/user/repos?page=2",
+		"last": "https://api.github.com/user/repos?page=5",
+	}
+	if got := parseLinkHeader(header); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLinkHeader = %v, want %v", got, want)
+	}
+
+	if got := parseLinkHeader(""); len(got) != 0 {
+		t.Errorf("parseLinkHeader of no header = %v, want none", got)
+	}
+
+	if n, err := pageNumber(want["last"]); err != nil || n != 5 {
+		t.Errorf("pageNumber = %d, %v, want 5", n, err)
+	}
+}
@@ -0,0 +1,174 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	repoFields = `pageInfo { hasNextPage endCursor }
+      nodes {
+        nameWithOwner
+        sshUrl
+        isFork
+        pushedAt
+        diskUsage
+        owner { login }
+        primaryLanguage { name }
+      }`
+
+	viewerReposQuery = `query($cursor: String, $affiliations: [RepositoryAffiliation]) {
+  viewer {
+    repositories(first: 100, after: $cursor, ownerAffiliations: $affiliations) {
+      ` + repoFields + `
+    }
+  }
+}`
+
+	orgReposQuery = `query($cursor: String, $org: String!) {
+  organization(login: $org) {
+    repositories(first: 100, after: $cursor) {
+      ` + repoFields + `
+    }
+  }
+}`
+)
+
+type (
+	graphqlRequest struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
+	}
+
+	graphqlResponse[T any] struct {
+		Data   T `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	repoConnection struct {
+		PageInfo struct {
+			HasNextPage bool   `json:"hasNextPage"`
+			EndCursor   string `json:"endCursor"`
+		} `json:"pageInfo"`
+		Nodes []struct {
+			NameWithOwner string    `json:"nameWithOwner"`
+			SshUrl        string    `json:"sshUrl"`
+			IsFork        bool      `json:"isFork"`
+			PushedAt      time.Time `json:"pushedAt"`
+			DiskUsage     int64     `json:"diskUsage"`
+			Owner         Owner     `json:"owner"`
+			Language      *struct {
+				Name string `json:"name"`
+			} `json:"primaryLanguage"`
+		} `json:"nodes"`
+	}
+
+	// reposQueryData holds the result of either query, only one of Viewer
+	// or Organization is set.
+	reposQueryData struct {
+		Viewer *struct {
+			Repositories repoConnection `json:"repositories"`
+		} `json:"viewer"`
+		Organization *struct {
+			Repositories repoConnection `json:"repositories"`
+		} `json:"organization"`
+	}
+)
+
+// GetAllReposWithLanguages lists the repositories in scope (see SetScope)
+// along with their primary language using the GraphQL API, costing one
+// request per 100 repos instead of one per repo.
+func (g Github) GetAllReposWithLanguages(ctx context.Context) ([]Repository, error) {
+	rv := make([]Repository, 0)
+	seen := make(map[string]struct{}, 0)
+	add := func(repos []Repository) {
+		for _, r := range repos {
+			if _, ok := seen[r.FullName]; !ok {
+				seen[r.FullName] = struct{}{}
+				rv = append(rv, r)
+			}
+		}
+	}
+
+	if len(g.affiliations) > 0 {
+		affiliations := make([]string, 0)
+		for _, a := range g.affiliations {
+			affiliations = append(affiliations, strings.ToUpper(a))
+		}
+
+		repos, err := queryRepos(ctx, g, viewerReposQuery, map[string]any{"affiliations": affiliations})
+		if err != nil {
+			return nil, err
+		}
+		add(repos)
+	}
+
+	for _, org := range g.orgs {
+		repos, err := queryRepos(ctx, g, orgReposQuery, map[string]any{"org": org})
+		if err != nil {
+			return nil, fmt.Errorf("org %s: %w", org, err)
+		}
+		add(repos)
+	}
+
+	return rv, nil
+}
+
+func queryRepos(ctx context.Context, g Github, query string, variables map[string]any) ([]Repository, error) {
+	rv := make([]Repository, 0)
+	var cursor *string
+	for {
+		variables["cursor"] = cursor
+		req := graphqlRequest{query, variables}
+
+		// queries are read only, so safe to retry despite being POSTs
+		res, err := execute[graphqlResponse[reposQueryData]](ctx, g, http.MethodPost, g.graphqlUrl(), req, true)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(res.Errors) > 0 {
+			msgs := make([]string, 0)
+			for _, e := range res.Errors {
+				msgs = append(msgs, e.Message)
+			}
+			return nil, errors.New(strings.Join(msgs, "; "))
+		}
+
+		var repos repoConnection
+		switch {
+		case res.Data.Viewer != nil:
+			repos = res.Data.Viewer.Repositories
+		case res.Data.Organization != nil:
+			repos = res.Data.Organization.Repositories
+		}
+
+		for _, n := range repos.Nodes {
+			owner := n.Owner
+			repo := Repository{
+				FullName: n.NameWithOwner,
+				SshUrl:   n.SshUrl,
+				Fork:     n.IsFork,
+				PushedAt: n.PushedAt,
+				Size:     n.DiskUsage,
+				Owner:    &owner,
+				Language: "unknown",
+			}
+			if n.Language != nil {
+				repo.Language = n.Language.Name
+			}
+			rv = append(rv, repo)
+		}
+
+		if !repos.PageInfo.HasNextPage {
+			return rv, nil
+		}
+		cursor = &repos.PageInfo.EndCursor
+	}
+}
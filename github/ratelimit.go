@@ -0,0 +1,109 @@
+package github
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRequestBudget = time.Minute
+	requestTimeout       = 5 * time.Second
+
+	baseBackoff = 500 * time.Millisecond
+	maxBackoff  = 30 * time.Second
+)
+
+// RateLimitError is returned when GitHub refuses a request because the rate
+// limit is exhausted and it will not reset within the request budget.
+type RateLimitError struct {
+	Limit, Remaining int
+	Reset            time.Time
+}
+
+func (e RateLimitError) Error() string {
+	return fmt.Sprintf(
+		"github rate limit exceeded (%d/%d remaining), resets at %s (in %s)",
+		e.Remaining,
+		e.Limit,
+		e.Reset.Local().Format("15:04:05"),
+		time.Until(e.Reset).Round(time.Second),
+	)
+}
+
+// retryDelay decides whether a failed attempt should be retried and how long
+// to wait first. res is nil for network errors. A non-nil RateLimitError is
+// returned when the primary rate limit has been exhausted.
+//
+// Only idempotent requests, reads and GraphQL queries, are retried after
+// network errors and 5xx responses, a create or delete may have taken effect
+// before failing. Others are only retried when GitHub explicitly asks for it
+// with Retry-After.
+func retryDelay(idempotent bool, res *response, attempt int) (time.Duration, bool, error) {
+	if res == nil {
+		return backoff(attempt), idempotent, nil
+	}
+
+	switch {
+	case res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusForbidden:
+		if wait, ok := retryAfter(res.Header); ok {
+			return wait, true, nil
+		}
+
+		if res.Header.Get("X-RateLimit-Remaining") == "0" {
+			rlErr := rateLimitError(res.Header)
+			return time.Until(rlErr.Reset), idempotent, rlErr
+		}
+
+		if res.StatusCode == http.StatusTooManyRequests {
+			return backoff(attempt), idempotent, nil
+		}
+
+		return 0, false, nil
+	case res.StatusCode >= 500:
+		return backoff(attempt), idempotent, nil
+	}
+
+	return 0, false, nil
+}
+
+func retryAfter(header http.Header) (time.Duration, bool) {
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t), true
+	}
+
+	return 0, false
+}
+
+func rateLimitError(header http.Header) RateLimitError {
+	limit, _ := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	remaining, _ := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	reset, _ := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+
+	return RateLimitError{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Unix(reset, 0),
+	}
+}
+
+// backoff returns an exponential delay with jitter in [d/2, d).
+func backoff(attempt int) time.Duration {
+	d := baseBackoff << attempt
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
@@ -0,0 +1,111 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	header := func(kv ...string) http.Header {
+		h := http.Header{}
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return h
+	}
+	res := func(status int, h http.Header) *response {
+		return &response{&http.Response{StatusCode: status, Header: h}, nil}
+	}
+
+	// reads and GraphQL queries are idempotent, creates and deletes aren't
+	tests := []struct {
+		name       string
+		idempotent bool
+		res        *response
+		retry      bool
+	}{
+		{"get network error", true, nil, true},
+		{"post network error", false, nil, false},
+		{"get 502", true, res(http.StatusBadGateway, header()), true},
+		{"graphql post 502", true, res(http.StatusBadGateway, header()), true},
+		{"post 502", false, res(http.StatusBadGateway, header()), false},
+		{"delete 503", false, res(http.StatusServiceUnavailable, header()), false},
+		{"get 429", true, res(http.StatusTooManyRequests, header()), true},
+		{"post 429", false, res(http.StatusTooManyRequests, header()), false},
+		{"post 429 retry-after", false, res(http.StatusTooManyRequests, header("Retry-After", "1")), true},
+		{"delete 403 retry-after", false, res(http.StatusForbidden, header("Retry-After", "1")), true},
+		{"post 403 exhausted", false, res(http.StatusForbidden, header("X-RateLimit-Remaining", "0")), false},
+		{"get 403 exhausted", true, res(http.StatusForbidden, header("X-RateLimit-Remaining", "0")), true},
+		{"get 403", true, res(http.StatusForbidden, header()), false},
+		{"get 404", true, res(http.StatusNotFound, header()), false},
+		{"post 422", false, res(http.StatusUnprocessableEntity, header()), false},
+	}
+
+	for _, tt := range tests {
+		if _, retry, _ := retryDelay(tt.idempotent, tt.res, 0); retry != tt.retry {
+			t.Errorf("%s: retry = %v, want %v", tt.name, retry, tt.retry)
+		}
+	}
+}
+
+func TestCreateRepoIsNotRetriedOn5xx(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, `{"message":"bad gateway"}`, http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	g := New("token", "me")
+	if err := g.SetEndpoints(srv.URL, "github.test"); err != nil {
+		t.Fatal(err)
+	}
+	g.SetRequestBudget(10 * time.Second)
+
+	if _, err := g.CreateRepo(context.Background(), "new", true); err == nil {
+		t.Error("CreateRepo succeeded against a failing server")
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("CreateRepo made %d requests, want 1", n)
+	}
+}
+
+func TestGraphqlQueryIsRetriedOn5xx(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/graphql" {
+			http.NotFound(w, r)
+			return
+		}
+		if atomic.AddInt32(&calls, 1) == 1 {
+			http.Error(w, `{"message":"bad gateway"}`, http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"data":{"viewer":{"repositories":{
+			"pageInfo":{"hasNextPage":false},
+			"nodes":[{"nameWithOwner":"me/foo","owner":{"login":"me"},"primaryLanguage":{"name":"Go"}}]
+		}}}}`))
+	}))
+	defer srv.Close()
+
+	g := New("token", "me")
+	if err := g.SetEndpoints(srv.URL, "github.test"); err != nil {
+		t.Fatal(err)
+	}
+	g.SetRequestBudget(10 * time.Second)
+
+	repos, err := g.GetAllReposWithLanguages(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllReposWithLanguages failed: %v", err)
+	}
+	if len(repos) != 1 || repos[0].Language != "Go" {
+		t.Errorf("GetAllReposWithLanguages = %v, want me/foo in Go", repos)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("made %d requests, want the 502 retried once", n)
+	}
+}
@@ -0,0 +1,215 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sgit/provider"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultBaseUrl = "https://gitlab.com"
+
+	perPage = 100
+)
+
+type (
+	Project struct {
+		Path              string     `json:"path"`
+		PathWithNamespace string     `json:"path_with_namespace"`
+		SshUrl            string     `json:"ssh_url_to_repo"`
+		ForkedFromProject *struct{}  `json:"forked_from_project"`
+		Namespace         *Namespace `json:"namespace"`
+		LastActivityAt    time.Time  `json:"last_activity_at"`
+		// Statistics is only returned to members with at least reporter access.
+		Statistics *struct {
+			RepositorySize int64 `json:"repository_size"`
+		} `json:"statistics"`
+	}
+
+	Namespace struct {
+		FullPath string `json:"full_path"`
+	}
+
+	User struct {
+		Username string `json:"username"`
+	}
+
+	Gitlab struct {
+		token, baseUrl string
+	}
+)
+
+func New(token, baseUrl string) *Gitlab {
+	if baseUrl == "" {
+		baseUrl = DefaultBaseUrl
+	}
+	return &Gitlab{token, strings.TrimSuffix(baseUrl, "/")}
+}
+
+func (g Gitlab) Host() string {
+	u, err := url.Parse(g.baseUrl)
+	if err != nil {
+		return g.baseUrl
+	}
+	return u.Host
+}
+
+func (g Gitlab) GetUsername(ctx context.Context) (string, error) {
+	user, _, err := execute[User](ctx, g, http.MethodGet, "/user", nil)
+	if err != nil {
+		return "", err
+	}
+	return user.Username, nil
+}
+
+// GetAllRepos returns every project the token's user is a member of. GitLab
+// has no cheap way to list languages alongside projects, so Language is left
+// empty.
+func (g Gitlab) GetAllRepos(ctx context.Context) ([]provider.Repository, error) {
+	rv := make([]provider.Repository, 0)
+	page := "1"
+	for page != "" {
+		e := fmt.Sprintf("/projects?membership=true&archived=false&statistics=true&per_page=%d&page=%s", perPage, page)
+		projects, header, err := execute[[]Project](ctx, g, http.MethodGet, e, nil)
+		if err != nil {
+			return rv, fmt.Errorf("page %s: %w", page, err)
+		}
+
+		for _, p := range *projects {
+			rv = append(rv, p.toProvider())
+		}
+		page = header.Get("X-Next-Page")
+	}
+
+	return rv, nil
+}
+
+// GetPrimaryLanguageForRepo returns the language GitLab reports the largest
+// share for, or "unknown" if it reports none.
+func (g Gitlab) GetPrimaryLanguageForRepo(ctx context.Context, owner, name string) (string, error) {
+	e := fmt.Sprintf("/projects/%s/languages", projectId(owner, name))
+	langs, _, err := execute[map[string]float64](ctx, g, http.MethodGet, e, nil)
+	if err != nil {
+		return "", err
+	}
+
+	primaryLanguage := "unknown"
+	maxShare := -1.0
+	for k, v := range *langs {
+		if v > maxShare {
+			primaryLanguage = k
+			maxShare = v
+		}
+	}
+	return primaryLanguage, nil
+}
+
+func (g Gitlab) CreateRepo(ctx context.Context, name string, private bool) (*provider.Repository, error) {
+	visibility := "public"
+	if private {
+		visibility = "private"
+	}
+
+	json := struct {
+		Name       string `json:"name"`
+		Visibility string `json:"visibility"`
+	}{name, visibility}
+
+	project, _, err := execute[Project](ctx, g, http.MethodPost, "/projects", json)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := project.toProvider()
+	return &rv, nil
+}
+
+func (g Gitlab) DeleteRepo(ctx context.Context, owner, name string) error {
+	e := "/projects/" + projectId(owner, name)
+	_, _, err := execute[struct{}](ctx, g, http.MethodDelete, e, nil)
+	return err
+}
+
+func (p Project) toProvider() provider.Repository {
+	rv := provider.Repository{
+		Name:     p.Path,
+		SshUrl:   p.SshUrl,
+		Fork:     p.ForkedFromProject != nil,
+		PushedAt: p.LastActivityAt,
+	}
+
+	if p.Statistics != nil {
+		rv.Size = p.Statistics.RepositorySize / 1024
+	}
+
+	if p.Namespace != nil {
+		rv.Owner = p.Namespace.FullPath
+	}
+
+	return rv
+}
+
+// projectId returns the url-encoded "namespace/project" form GitLab accepts
+// in place of a numeric project id.
+func projectId(owner, name string) string {
+	return url.PathEscape(owner + "/" + name)
+}
+
+func execute[T any](ctx context.Context, g Gitlab, verb, endpoint string, body any) (*T, http.Header, error) {
+	url := g.baseUrl + "/api/v4" + endpoint
+
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("json.Marshal failed: %w", err)
+		}
+		r = bytes.NewBuffer(b)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, verb, url, r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Add("PRIVATE-TOKEN", g.token)
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	client := &http.Client{}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		msg := fmt.Sprintf("%s: %s", res.Status, resBody)
+		return nil, nil, errors.New(msg)
+	}
+
+	if len(resBody) == 0 {
+		return new(T), res.Header, nil
+	}
+
+	t := new(T)
+	err = json.Unmarshal(resBody, t)
+	return t, res.Header, err
+}
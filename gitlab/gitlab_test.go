@@ -0,0 +1,231 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testToken = "secret"
+
+// fakeServer is an in-memory GitLab serving the endpoints Gitlab uses.
+type fakeServer struct {
+	mu       sync.Mutex
+	projects []Project
+	langs    map[string]map[string]float64
+	pages    []int
+	// failPage is a page of /projects that errors, 0 for none
+	failPage int
+	// paths holds the escaped path of every request
+	paths []string
+}
+
+func newFakeServer(t *testing.T, projects ...Project) (*fakeServer, *Gitlab) {
+	t.Helper()
+
+	f := &fakeServer{projects: projects, langs: make(map[string]map[string]float64, 0)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	return f, New(testToken, srv.URL+"/")
+}
+
+func project(namespace, path string) Project {
+	return Project{
+		Path:              path,
+		PathWithNamespace: namespace + "/" + path,
+		SshUrl:            fmt.Sprintf("git@gitlab.test:%s/%s.git", namespace, path),
+		Namespace:         &Namespace{namespace},
+	}
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("PRIVATE-TOKEN") != testToken {
+		http.Error(w, `{"message":"401 Unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	// project ids are a single escaped segment, e.g. group%2Fsub%2Fname
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/api/v4")
+	f.paths = append(f.paths, path)
+	p := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case r.Method == http.MethodGet && path == "/projects":
+		f.listProjects(w, r)
+	case r.Method == http.MethodGet && len(p) == 3 && p[0] == "projects" && p[2] == "languages":
+		json.NewEncoder(w).Encode(f.langs[unescape(p[1])])
+	case r.Method == http.MethodDelete && len(p) == 2 && p[0] == "projects":
+		f.deleteProject(w, unescape(p[1]))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func unescape(id string) string {
+	return strings.ReplaceAll(id, "%2F", "/")
+}
+
+func (f *fakeServer) listProjects(w http.ResponseWriter, r *http.Request) {
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if perPage <= 0 || page <= 0 {
+		http.Error(w, "bad paging", http.StatusBadRequest)
+		return
+	}
+	f.pages = append(f.pages, page)
+	if page == f.failPage {
+		http.Error(w, `{"message":"boom"}`, http.StatusInternalServerError)
+		return
+	}
+
+	start, end := (page-1)*perPage, page*perPage
+	if start > len(f.projects) {
+		start = len(f.projects)
+	}
+	if end < len(f.projects) {
+		w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+	} else {
+		end = len(f.projects)
+		// GitLab sends the header empty on the last page
+		w.Header().Set("X-Next-Page", "")
+	}
+	json.NewEncoder(w).Encode(f.projects[start:end])
+}
+
+func (f *fakeServer) deleteProject(w http.ResponseWriter, id string) {
+	for idx, p := range f.projects {
+		if p.PathWithNamespace == id {
+			f.projects = append(f.projects[:idx], f.projects[idx+1:]...)
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+	}
+	http.NotFound(w, nil)
+}
+
+func manyProjects(n int) []Project {
+	rv := make([]Project, 0)
+	for i := 0; i < n; i++ {
+		rv = append(rv, project("me", fmt.Sprintf("project-%03d", i)))
+	}
+	return rv
+}
+
+func TestGetAllReposFollowsNextPage(t *testing.T) {
+	tests := []struct {
+		projects int
+		pages    []int
+	}{
+		{0, []int{1}},
+		{perPage, []int{1}},
+		{perPage + 1, []int{1, 2}},
+		{2*perPage + 50, []int{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		f, g := newFakeServer(t, manyProjects(tt.projects)...)
+		repos, err := g.GetAllRepos(context.Background())
+		if err != nil {
+			t.Fatalf("%d projects: GetAllRepos failed: %v", tt.projects, err)
+		}
+
+		if len(repos) != tt.projects {
+			t.Errorf("%d projects: got %d", tt.projects, len(repos))
+		}
+		if fmt.Sprint(f.pages) != fmt.Sprint(tt.pages) {
+			t.Errorf("%d projects: requested pages %v, want %v", tt.projects, f.pages, tt.pages)
+		}
+	}
+}
+
+func TestGetAllReposReturnsPartialPages(t *testing.T) {
+	f, g := newFakeServer(t, manyProjects(2*perPage+50)...)
+	f.failPage = 2
+
+	repos, err := g.GetAllRepos(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "page 2") {
+		t.Errorf("GetAllRepos returned %v, want a page 2 error", err)
+	}
+	if len(repos) != perPage {
+		t.Errorf("got %d repos, want the %d from page 1", len(repos), perPage)
+	}
+}
+
+func TestGetAllReposNestedGroups(t *testing.T) {
+	fork := project("group/sub/deeper", "forked")
+	fork.ForkedFromProject = &struct{}{}
+	_, g := newFakeServer(t, project("me", "mine"), project("group/sub", "nested"), fork)
+
+	repos, err := g.GetAllRepos(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllRepos failed: %v", err)
+	}
+
+	want := []struct {
+		owner, name string
+		fork        bool
+	}{
+		{"me", "mine", false},
+		{"group/sub", "nested", false},
+		{"group/sub/deeper", "forked", true},
+	}
+	if len(repos) != len(want) {
+		t.Fatalf("got %d repos, want %d", len(repos), len(want))
+	}
+	for idx, w := range want {
+		r := repos[idx]
+		if r.Owner != w.owner || r.Name != w.name || r.Fork != w.fork {
+			t.Errorf("repo %d = %s/%s fork %t, want %s/%s fork %t", idx, r.Owner, r.Name, r.Fork, w.owner, w.name, w.fork)
+		}
+	}
+}
+
+func TestGetPrimaryLanguageForRepo(t *testing.T) {
+	f, g := newFakeServer(t, project("group/sub", "nested"))
+	f.langs["group/sub/nested"] = map[string]float64{"Go": 80.5, "Shell": 19.5}
+
+	lang, err := g.GetPrimaryLanguageForRepo(context.Background(), "group/sub", "nested")
+	if err != nil {
+		t.Fatalf("GetPrimaryLanguageForRepo failed: %v", err)
+	} else if lang != "Go" {
+		t.Errorf("got %q, want Go", lang)
+	}
+
+	if want := "/projects/group%2Fsub%2Fnested/languages"; f.paths[len(f.paths)-1] != want {
+		t.Errorf("requested %s, want %s", f.paths[len(f.paths)-1], want)
+	}
+
+	lang, err = g.GetPrimaryLanguageForRepo(context.Background(), "me", "empty")
+	if err != nil {
+		t.Fatalf("GetPrimaryLanguageForRepo failed: %v", err)
+	} else if lang != "unknown" {
+		t.Errorf("got %q for a project without languages, want unknown", lang)
+	}
+}
+
+func TestDeleteRepo(t *testing.T) {
+	f, g := newFakeServer(t, project("group/sub", "nested"), project("group", "sub"))
+
+	if err := g.DeleteRepo(context.Background(), "group/sub", "nested"); err != nil {
+		t.Fatalf("DeleteRepo failed: %v", err)
+	}
+	if want := "/projects/group%2Fsub%2Fnested"; f.paths[len(f.paths)-1] != want {
+		t.Errorf("requested %s, want %s", f.paths[len(f.paths)-1], want)
+	}
+	if len(f.projects) != 1 || f.projects[0].PathWithNamespace != "group/sub" {
+		t.Errorf("projects left = %v, want only group/sub", f.projects)
+	}
+
+	if err := g.DeleteRepo(context.Background(), "group/sub", "nested"); err == nil {
+		t.Error("DeleteRepo of a missing project succeeded")
+	}
+}
@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sgit/internal/credentials"
+	"sgit/internal/interactor"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	host, username *string
+
+	Cmd = &cobra.Command{
+		Use:   "auth",
+		Short: "show and manage the token sgit uses",
+		Long:  "tokens are looked up in the config file and env, gh's hosts.yml, git credential helpers and finally sgit's own encrypted store",
+	}
+
+	statusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "show where each profile's token comes from",
+		Args:  cobra.NoArgs,
+		RunE:  status,
+	}
+
+	storeCmd = &cobra.Command{
+		Use:   "store",
+		Short: "save a token read from stdin to sgit's encrypted store, e.g. gh auth token | sgit auth store",
+		Args:  cobra.NoArgs,
+		RunE:  store,
+	}
+
+	eraseCmd = &cobra.Command{
+		Use:   "erase",
+		Short: "remove a token from sgit's encrypted store",
+		Args:  cobra.NoArgs,
+		RunE:  erase,
+	}
+)
+
+func init() {
+	host = Cmd.PersistentFlags().String("host", "", "host the token is for, defaults to the first active profile's host")
+	username = Cmd.PersistentFlags().String("username", "", "account the token belongs to, defaults to the first active profile's username")
+	Cmd.AddCommand(statusCmd, storeCmd, eraseCmd)
+}
+
+// target is the host and username to store a token for, from the flags or
+// the first active profile.
+func target() (string, string) {
+	p := interactor.ActiveProfiles()[0]
+	h, u := p.Host, p.Username
+	if *host != "" {
+		h = *host
+	}
+	if *username != "" {
+		u = *username
+	}
+	return h, u
+}
+
+func status(cmd *cobra.Command, args []string) error {
+	for _, p := range interactor.ActiveProfiles() {
+		cred, err := p.Credential(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("profile %s: %w", p.Name, err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "%s: %s token from %s", p.Name, cred.Host, cred.Source)
+		if cred.Username != "" {
+			fmt.Fprintf(cmd.OutOrStdout(), " for %s", cred.Username)
+		}
+		fmt.Fprintln(cmd.OutOrStdout())
+	}
+	return nil
+}
+
+func store(cmd *cobra.Command, args []string) error {
+	token, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	token = strings.TrimSpace(token)
+	if token == "" {
+		if err != nil {
+			return fmt.Errorf("failed to read token from stdin: %w", err)
+		}
+		return errors.New("no token on stdin")
+	}
+
+	s, err := credentials.OpenStore()
+	if err != nil {
+		return fmt.Errorf("credentials.OpenStore failed: %w", err)
+	}
+
+	h, u := target()
+	s.Put(h, u, token)
+	if err := s.Save(); err != nil {
+		return fmt.Errorf("store.Save failed: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Stored token for %s in %s\n", h, s.Path())
+	return nil
+}
+
+func erase(cmd *cobra.Command, args []string) error {
+	s, err := credentials.OpenStore()
+	if err != nil {
+		return fmt.Errorf("credentials.OpenStore failed: %w", err)
+	}
+
+	if h, u := target(); !s.Delete(h, u) {
+		return fmt.Errorf("no stored token for %s", h)
+	}
+
+	if err := s.Save(); err != nil {
+		return fmt.Errorf("store.Save failed: %w", err)
+	}
+	return nil
+}
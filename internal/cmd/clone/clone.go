@@ -1,22 +1,20 @@
 package clone
 
 import (
-	"bufio"
+	"context"
 	"errors"
 	"fmt"
-	"os"
 	"sgit/internal/interactor"
 	"sgit/internal/tui"
 	"strings"
 	"sync"
 
-	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
 var (
 	langs, names *string
-	forks        *bool
+	forks, yes   *bool
 )
 
 var Cmd = &cobra.Command{
@@ -30,6 +28,7 @@ func init() {
 	langs = Cmd.PersistentFlags().StringP("lang", "l", "", "comma-separated string of languages to target")
 	forks = Cmd.PersistentFlags().BoolP("fork", "f", false, "target forked or non-forked repos")
 	names = Cmd.PersistentFlags().StringP("name", "n", "", "comma-separated string of repo names to target")
+	yes = Cmd.PersistentFlags().BoolP("yes", "y", false, "clone without asking for confirmation")
 }
 
 func run(cmd *cobra.Command, args []string) error {
@@ -38,11 +37,15 @@ func run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if proceed := showPrompt(repos); proceed {
-		return clone(repos)
+	if len(repos) == 0 {
+		return nil
 	}
 
-	return nil
+	if err := showPrompt(repos); err != nil {
+		return err
+	}
+
+	return clone(cmd.Context(), repos)
 }
 
 func getTargets(cmd *cobra.Command, args []string) ([]interactor.Repo, error) {
@@ -57,7 +60,10 @@ func getTargets(cmd *cobra.Command, args []string) ([]interactor.Repo, error) {
 			return nil, fmt.Errorf("interactor.NewFilter failed: %w", err)
 		}
 
-		i := interactor.New()
+		i, err := interactor.New(cmd.Context())
+		if err != nil {
+			return nil, fmt.Errorf("interactor.New failed: %w", err)
+		}
 
 		langToRepoStatePairs, err := i.GetRepoStates(cmd.Context(), *filter)
 		if err != nil {
@@ -74,7 +80,10 @@ func getTargets(cmd *cobra.Command, args []string) ([]interactor.Repo, error) {
 		return rv, nil
 	}
 
-	i := interactor.New()
+	i, err := interactor.New(cmd.Context())
+	if err != nil {
+		return nil, fmt.Errorf("interactor.New failed: %w", err)
+	}
 
 	type result struct {
 		interactor.Repo
@@ -82,6 +91,7 @@ func getTargets(cmd *cobra.Command, args []string) ([]interactor.Repo, error) {
 		exists bool
 	}
 
+	sem := make(chan struct{}, i.Concurrency())
 	results := make(chan result, len(args))
 	errs := make([]error, 0)
 	var wg sync.WaitGroup
@@ -90,9 +100,20 @@ func getTargets(cmd *cobra.Command, args []string) ([]interactor.Repo, error) {
 
 		go func(arg string) {
 			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
 
-			repo := normalize(arg)
-			lang, err := i.GetPrimaryLanguageForRepo(cmd.Context(), repo.Owner, repo.Name)
+			repo, err := i.RepoFromArg(arg)
+			if err != nil {
+				results <- result{
+					repo,
+					fmt.Errorf("%s: %w", arg, err),
+					false,
+				}
+				return
+			}
+
+			lang, err := i.GetPrimaryLanguageForRepo(cmd.Context(), repo)
 			if err != nil {
 				results <- result{
 					repo,
@@ -144,75 +165,48 @@ func getTargets(cmd *cobra.Command, args []string) ([]interactor.Repo, error) {
 
 	return rv, nil
 }
-func normalize(arg string) interactor.Repo {
-	p := strings.Split(arg, "/")
-
-	name, username := "", ""
-	if len(p) > 1 {
-		name = strings.Split(p[len(p)-1], ".")[0]
 
-		p := strings.Split(p[len(p)-2], ":")
-		username = p[len(p)-1]
-	} else {
-		name = strings.Split(p[len(p)-1], ".")[0]
-		username = os.Getenv("GITHUB_USERNAME")
+func showPrompt(repos []interactor.Repo) error {
+	tui.Output(repos)
+	if *yes {
+		return nil
 	}
 
-	return interactor.Repo{
-		Name:  name,
-		Owner: username,
-		URL:   fmt.Sprintf("https://github.com/%s/%s", username, name),
-	}
-}
-
-func showPrompt(repos []interactor.Repo) bool {
-	if len(repos) == 0 {
-		return false
+	msg := "You're about to clone 1 repo"
+	if len(repos) > 1 {
+		msg = fmt.Sprintf("You're about to clone %d repos", len(repos))
 	}
 
-	tui.Output(repos)
-
-	reader := bufio.NewReader(os.Stdin)
-
-	proceed := false
-	for {
-		msg := "You're about to clone 1 repo"
-		if len(repos) > 1 {
-			msg = fmt.Sprintf("You're about to clone %d repos", len(repos))
-		}
-		msg += ", would you like to proceed? (Y/N): "
-		d := color.New(color.FgGreen, color.Bold)
-		d.Print(msg)
-
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(strings.ToUpper(input))
-
-		if input == "Y" {
-			proceed = true
-			break
-		} else if input == "N" {
-			break
-		} else {
-			fmt.Println("Invalid input. Please enter Y or N.")
-		}
+	proceed, err := tui.Confirm(msg + ", would you like to proceed?")
+	if err != nil {
+		return err
+	} else if !proceed {
+		return tui.ErrDeclined
 	}
 
-	return proceed
+	return nil
 }
 
-func clone(repos []interactor.Repo) error {
-	i := interactor.New()
+func clone(ctx context.Context, repos []interactor.Repo) error {
+	i, err := interactor.New(ctx)
+	if err != nil {
+		return fmt.Errorf("interactor.New failed: %w", err)
+	}
 
 	tui.PrintProgress(0.0)
 	complete := 0
 	errs := make([]error, 0)
+	sem := make(chan struct{}, i.Concurrency())
 	results := make(chan error, len(repos))
 	var wg sync.WaitGroup
 	for _, repo := range repos {
 		wg.Add(1)
 		go func(r interactor.Repo) {
 			defer wg.Done()
-			results <- i.Clone(r)
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results <- i.Clone(ctx, r)
 		}(repo)
 	}
 
@@ -2,43 +2,126 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sgit/internal/cmd/auth"
 	"sgit/internal/cmd/clone"
+	"sgit/internal/cmd/configure"
 	"sgit/internal/cmd/create"
 	del "sgit/internal/cmd/delete"
+	"sgit/internal/cmd/initialize"
 	"sgit/internal/cmd/ls"
+	"sgit/internal/cmd/organize"
+	"sgit/internal/cmd/sync"
+	"sgit/internal/cmd/wip"
+	"sgit/internal/config"
+	"sgit/internal/tui"
 
 	"github.com/spf13/cobra"
 )
 
+const (
+	exitError    = 1
+	exitDeclined = 2
+)
+
 var cmd = &cobra.Command{
 	Use:   "sgit",
 	Short: "git made simple",
 	Long:  "git made simple",
+	// Execute prints errors once, without the usage text cobra adds
+	SilenceErrors:     true,
+	SilenceUsage:      true,
+	PersistentPreRunE: preRun,
 }
 
-func Execute() {
-	assert("GITHUB_TOKEN")
-	assert("GITHUB_USERNAME")
-	assert("CODE_HOME_DIR")
+// flagDefaults maps the flags commands share to the settings that provide
+// their defaults, flags passed explicitly win.
+var flagDefaults = map[string]string{
+	"concurrency": config.Concurrency,
+}
 
-	ctx := context.Background()
-	if err := cmd.ExecuteContext(ctx); err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+// filterDefaults are flagDefaults for the repo filters. They only apply to
+// filteringCmds: a saved filter quietly changing what clone or delete act on
+// would be a surprise, and create's --name is the new repo's name.
+var filterDefaults = map[string]string{
+	"lang":  config.FilterLang,
+	"state": config.FilterState,
+	"name":  config.FilterName,
+}
+
+var filteringCmds = []*cobra.Command{ls.Cmd, organize.Cmd, sync.Cmd}
+
+var profile *string
+
+// preRun validates the configuration before any command touches repos.
+func preRun(c *cobra.Command, args []string) error {
+	if c.Flags().Changed("profile") {
+		config.Override(config.Profiles, *profile)
+	}
+
+	// a --concurrency flag also bounds the listing done before the command's
+	// own work
+	if f := c.Flags().Lookup("concurrency"); f != nil && f.Changed {
+		config.Override(config.Concurrency, f.Value.String())
+	}
+
+	if err := config.Validate(); err != nil {
+		return err
 	}
+
+	if err := applyDefaults(c, flagDefaults); err != nil {
+		return err
+	}
+
+	for _, f := range filteringCmds {
+		if c == f {
+			return applyDefaults(c, filterDefaults)
+		}
+	}
+	return nil
 }
 
-func assert(envVar string) {
-	if _, ok := os.LookupEnv(envVar); !ok {
-		panic("Unset environment variable: " + envVar)
+func applyDefaults(c *cobra.Command, defaults map[string]string) error {
+	for flag, key := range defaults {
+		f := c.Flags().Lookup(flag)
+		v := config.Get(key)
+		if f == nil || f.Changed || v == "" {
+			continue
+		}
+
+		if err := c.Flags().Set(flag, v); err != nil {
+			return fmt.Errorf("invalid %s %q: %w", key, v, err)
+		}
+	}
+
+	return nil
+}
+
+func Execute() {
+	ctx := context.Background()
+	if err := cmd.ExecuteContext(ctx); err != nil {
+		if errors.Is(err, tui.ErrDeclined) {
+			os.Exit(exitDeclined)
+		}
+
+		fmt.Println(err.Error())
+		os.Exit(exitError)
 	}
 }
 
 func init() {
+	profile = cmd.PersistentFlags().StringP("profile", "P", "", "comma-separated profiles to use, all of them by default")
+
 	cmd.AddCommand(ls.Cmd)
+	cmd.AddCommand(auth.Cmd)
 	cmd.AddCommand(clone.Cmd)
+	cmd.AddCommand(configure.Cmd)
 	cmd.AddCommand(create.Cmd)
 	cmd.AddCommand(del.Cmd)
+	cmd.AddCommand(initialize.Cmd)
+	cmd.AddCommand(organize.Cmd)
+	cmd.AddCommand(sync.Cmd)
+	cmd.AddCommand(wip.Cmd)
 }
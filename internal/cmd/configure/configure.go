@@ -0,0 +1,129 @@
+package configure
+
+import (
+	"fmt"
+	"sgit/internal/config"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "config",
+		Short: "read and write sgit settings",
+		Long:  "read and write the settings in the config file, environment variables take precedence over the file",
+		// settings are being fixed here, so don't require them to be valid
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+	}
+
+	getCmd = &cobra.Command{
+		Use:   "get <key>",
+		Short: "print the effective value of a setting",
+		Args:  cobra.ExactArgs(1),
+		RunE:  get,
+	}
+
+	setCmd = &cobra.Command{
+		Use:   "set <key> [value]",
+		Short: "store a setting in the config file, omit the value to remove it",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE:  set,
+	}
+
+	listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "list every setting, profile and language mapping, their effective values and where they came from",
+		Args:  cobra.NoArgs,
+		RunE:  list,
+	}
+
+	pathCmd = &cobra.Command{
+		Use:   "path",
+		Short: "print the path of the config file",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Fprintln(cmd.OutOrStdout(), config.Path())
+		},
+	}
+)
+
+func init() {
+	Cmd.AddCommand(getCmd, setCmd, listCmd, pathCmd)
+}
+
+func get(cmd *cobra.Command, args []string) error {
+	if err := config.Check(args[0]); err != nil {
+		return err
+	}
+
+	if _, err := config.Load(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), config.Get(args[0]))
+	return nil
+}
+
+func set(cmd *cobra.Command, args []string) error {
+	c, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	value := ""
+	if len(args) > 1 {
+		value = args[1]
+	}
+
+	if err := c.Set(args[0], value); err != nil {
+		return err
+	}
+
+	if err := c.Save(); err != nil {
+		return fmt.Errorf("config.Save failed: %w", err)
+	}
+	return nil
+}
+
+func list(cmd *cobra.Command, args []string) error {
+	if _, err := config.Load(); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE\tENV")
+	for _, k := range config.Keys {
+		v, source := config.Resolve(k.Name)
+		if v == "" {
+			source = ""
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", k.Name, config.Display(k.Name, v), source, k.Env)
+	}
+
+	for _, profile := range config.ProfileNames() {
+		for _, f := range config.ProfileFields {
+			name := config.ProfileKey(profile, f.Name)
+			if v, source := config.Resolve(name); v != "" {
+				fmt.Fprintf(w, "%s\t%s\t%s\t\n", name, config.Display(name, v), source)
+			}
+		}
+	}
+
+	for _, table := range []string{config.Languages, config.Repos} {
+		values := config.Table(table)
+		keys := make([]string, 0)
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			fmt.Fprintf(w, "%s.%s\t%s\tfile\t\n", table, k, values[k])
+		}
+	}
+	return w.Flush()
+}
@@ -1,31 +1,67 @@
 package create
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sgit/internal/interactor"
 	"sgit/internal/tui"
-	"strings"
 
-	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
-var Cmd = &cobra.Command{
-	Use:   "create",
-	Short: "create a repo",
-	Long:  "create a repo",
-	RunE:  run,
+var (
+	name            *string
+	private, public *bool
+
+	Cmd = &cobra.Command{
+		Use:   "create [name]",
+		Short: "create a repo",
+		Long:  "create a repo",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  run,
+	}
+)
+
+func init() {
+	name = Cmd.PersistentFlags().String("name", "", "name of the repo, may also be given as an argument")
+	private = Cmd.PersistentFlags().Bool("private", false, "make the repo private")
+	public = Cmd.PersistentFlags().Bool("public", false, "make the repo public")
 }
 
 func run(cmd *cobra.Command, args []string) error {
-	name := showNamePrompt()
-	private := showPrivatePrompt()
+	if *private && *public {
+		return errors.New("--private and --public are mutually exclusive")
+	}
+
+	repoName := *name
+	if len(args) > 0 {
+		repoName = args[0]
+	}
+
+	var err error
+	if repoName == "" {
+		repoName, err = showNamePrompt()
+		if err != nil {
+			return err
+		}
+	}
+
+	isPrivate := *private
+	if !*private && !*public {
+		isPrivate, err = showPrivatePrompt()
+		if err != nil {
+			return err
+		}
+	}
 	tui.PrintProgress(0.0)
 
-	i := interactor.New()
-	repo, err := i.CreateRepo(cmd.Context(), name, private)
+	i, err := interactor.New(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("interactor.New failed: %w", err)
+	}
+	repo, err := i.CreateRepo(cmd.Context(), repoName, isPrivate, sourceDir())
 	if err != nil {
 		return fmt.Errorf("interactor.CreateRepo failed: %w", err)
 	}
@@ -36,7 +72,7 @@ func run(cmd *cobra.Command, args []string) error {
 	}
 
 	if !exists {
-		if err := i.Clone(*repo); err != nil {
+		if err := i.Clone(cmd.Context(), *repo); err != nil {
 			return fmt.Errorf("interactor.Clone failed: %w, err", err)
 		}
 	}
@@ -45,41 +81,29 @@ func run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func showNamePrompt() string {
-	reader := bufio.NewReader(os.Stdin)
-
-	for {
-		d := color.New(color.FgGreen, color.Bold)
-		d.Print("Name: ")
-
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(input)
-
-		if len(input) > 0 {
-			return input
-		} else {
-			fmt.Println("You must enter a non-empty repo name.")
-		}
+// sourceDir is the working tree to detect the new repo's language from: the
+// current directory if it is a git repo, otherwise none.
+func sourceDir() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	if _, err := os.Stat(filepath.Join(wd, ".git")); err != nil {
+		return ""
 	}
+	return wd
 }
 
-func showPrivatePrompt() bool {
-	reader := bufio.NewReader(os.Stdin)
-
-	for {
-		d := color.New(color.FgGreen, color.Bold)
-		d.Print("Private (y/n): ")
-
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(strings.ToLower(input))
-
-		if input == "y" {
-			return true
-		} else if input == "n" {
-			return false
-		} else {
-			fmt.Println("Invalid input. Please enter y or n.")
-		}
+func showNamePrompt() (string, error) {
+	return tui.Ask(
+		"Name: ",
+		"You must enter a non-empty repo name.",
+		func(s string) bool {
+			return len(s) > 0
+		},
+	)
+}
 
-	}
+func showPrivatePrompt() (bool, error) {
+	return tui.Confirm("Private")
 }
@@ -1,16 +1,13 @@
 package del
 
 import (
-	"bufio"
 	"errors"
 	"fmt"
-	"os"
 	"sgit/internal/interactor"
 	"sgit/internal/tui"
 	"strings"
 	"sync"
 
-	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
@@ -21,8 +18,8 @@ const (
 )
 
 var (
-	langs, states, names *string
-	forks                *bool
+	langs, states, names, target *string
+	forks, yes                   *bool
 
 	Cmd = &cobra.Command{
 		Use:   "delete",
@@ -35,8 +32,10 @@ var (
 func init() {
 	langs = Cmd.PersistentFlags().StringP("lang", "l", "", "comma-separated string of languages to target")
 	forks = Cmd.PersistentFlags().BoolP("fork", "f", false, "target forked or non-forked repos")
-	states = Cmd.PersistentFlags().StringP("state", "s", "", "comma-separated list of states to target")
+	states = Cmd.PersistentFlags().StringP("state", "s", "", "comma-separated list of states to target, join states with + to require all of them")
 	names = Cmd.PersistentFlags().StringP("name", "n", "", "comma-separated list of repo names to target")
+	target = Cmd.PersistentFlags().StringP("target", "t", "", "which copies to delete: local, remote or both")
+	yes = Cmd.PersistentFlags().BoolP("yes", "y", false, "delete without asking for confirmation")
 }
 
 func run(cmd *cobra.Command, args []string) error {
@@ -45,13 +44,24 @@ func run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if proceed := showPrompt(repos); !proceed {
+	if cmd.Flags().Changed("target") && !validTarget(*target) {
+		return fmt.Errorf("invalid --target \"%s\", valid targets: local remote both", *target)
+	}
+
+	if len(repos) == 0 {
 		return nil
 	}
 
-	selection := showLocalRemotePrompt()
+	if err := showPrompt(repos); err != nil {
+		return err
+	}
+
+	selection := *target
 	if selection == "" {
-		return nil
+		selection, err = showLocalRemotePrompt()
+		if err != nil {
+			return err
+		}
 	}
 
 	err = deleteRepos(cmd, repos, selection)
@@ -70,7 +80,10 @@ func getTargets(cmd *cobra.Command, args []string) ([]interactor.Repo, error) {
 			return nil, fmt.Errorf("interactor.NewFilter failed: %w", err)
 		}
 
-		i := interactor.New()
+		i, err := interactor.New(cmd.Context())
+		if err != nil {
+			return nil, fmt.Errorf("interactor.New failed: %w", err)
+		}
 
 		langToRepoStatePairs, err := i.GetRepoStates(cmd.Context(), *filter)
 		if err != nil {
@@ -87,7 +100,10 @@ func getTargets(cmd *cobra.Command, args []string) ([]interactor.Repo, error) {
 		return rv, nil
 	}
 
-	i := interactor.New()
+	i, err := interactor.New(cmd.Context())
+	if err != nil {
+		return nil, fmt.Errorf("interactor.New failed: %w", err)
+	}
 
 	type result struct {
 		interactor.Repo
@@ -95,6 +111,7 @@ func getTargets(cmd *cobra.Command, args []string) ([]interactor.Repo, error) {
 		exists bool
 	}
 
+	sem := make(chan struct{}, i.Concurrency())
 	results := make(chan result, len(args))
 	errs := make([]error, 0)
 	var wg sync.WaitGroup
@@ -103,9 +120,20 @@ func getTargets(cmd *cobra.Command, args []string) ([]interactor.Repo, error) {
 
 		go func(arg string) {
 			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			repo, err := i.RepoFromArg(arg)
+			if err != nil {
+				results <- result{
+					repo,
+					fmt.Errorf("%s: %w", arg, err),
+					false,
+				}
+				return
+			}
 
-			repo := normalize(arg)
-			lang, err := i.GetPrimaryLanguageForRepo(cmd.Context(), repo.Owner, repo.Name)
+			lang, err := i.GetPrimaryLanguageForRepo(cmd.Context(), repo)
 			if err != nil {
 				results <- result{
 					repo,
@@ -158,96 +186,64 @@ func getTargets(cmd *cobra.Command, args []string) ([]interactor.Repo, error) {
 	return rv, nil
 }
 
-func normalize(arg string) interactor.Repo {
-	p := strings.Split(arg, "/")
-
-	name, username := "", ""
-	if len(p) > 1 {
-		name = strings.Split(p[len(p)-1], ".")[0]
-
-		p := strings.Split(p[len(p)-2], ":")
-		username = p[len(p)-1]
-	} else {
-		name = strings.Split(p[len(p)-1], ".")[0]
-		username = os.Getenv("GITHUB_USERNAME")
+func showPrompt(repos []interactor.Repo) error {
+	tui.Output(repos)
+	if *yes {
+		return nil
 	}
 
-	return interactor.Repo{
-		Name:  name,
-		Owner: username,
-		URL:   fmt.Sprintf("https://github.com/%s/%s", name, username),
+	msg := "You're about to delete 1 repo"
+	if len(repos) > 1 {
+		msg = fmt.Sprintf("You're about to delete %d repos", len(repos))
 	}
-}
 
-func showPrompt(repos []interactor.Repo) bool {
-	if len(repos) == 0 {
-		return false
+	proceed, err := tui.Confirm(msg + ", would you like to proceed?")
+	if err != nil {
+		return err
+	} else if !proceed {
+		return tui.ErrDeclined
 	}
 
-	tui.Output(repos)
-
-	reader := bufio.NewReader(os.Stdin)
-
-	proceed := false
-	for {
-		msg := "You're about to delete 1 repo"
-		if len(repos) > 1 {
-			msg = fmt.Sprintf("You're about to delete %d repos", len(repos))
-		}
-		msg += ", would you like to proceed? (y/n): "
-		d := color.New(color.FgGreen, color.Bold)
-		d.Print(msg)
-
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(strings.ToLower(input))
-
-		if input == "y" {
-			proceed = true
-			break
-		} else if input == "n" {
-			break
-		} else {
-			fmt.Println("Invalid input. Please enter y or n.")
-		}
-	}
+	return nil
+}
 
-	return proceed
+func showLocalRemotePrompt() (string, error) {
+	input, err := tui.Ask(
+		"What repo would you like to delete? (local/remote/both): ",
+		"Invalid input. Please enter local, remote or both.",
+		func(s string) bool {
+			return validTarget(strings.ToLower(s))
+		},
+	)
+	return strings.ToLower(input), err
 }
 
-func showLocalRemotePrompt() string {
-	reader := bufio.NewReader(os.Stdin)
-	for {
-		d := color.New(color.FgGreen, color.Bold)
-		d.Print("What repo would you like to delete? (local/remote/both): ")
-
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(strings.ToLower(input))
-
-		switch input {
-		case local:
-			return input
-		case remote:
-			return input
-		case both:
-			return input
-		default:
-			fmt.Println("Invalid input. Please enter local, remote or both.")
-		}
+func validTarget(s string) bool {
+	switch s {
+	case local, remote, both:
+		return true
 	}
+	return false
 }
 
 func deleteRepos(cmd *cobra.Command, repos []interactor.Repo, target string) error {
-	i := interactor.New()
+	i, err := interactor.New(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("interactor.New failed: %w", err)
+	}
 
 	tui.PrintProgress(0.0)
 	complete := 0
 	errs := make([]error, 0)
+	sem := make(chan struct{}, i.Concurrency())
 	results := make(chan error, len(repos))
 	var wg sync.WaitGroup
 	for _, repo := range repos {
 		wg.Add(1)
 		go func(r interactor.Repo) {
 			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
 
 			errs := make([]error, 0)
 			if target == remote || target == both {
@@ -0,0 +1,62 @@
+package initialize
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sgit/internal/interactor"
+	"sgit/internal/tui"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	remote, private *bool
+
+	Cmd = &cobra.Command{
+		Use:   "init [name]",
+		Short: "create a repo in the current or named directory and move it into CODE_HOME_DIR",
+		Long:  "initialise a git repo in the current directory, or a directory named by the argument, detect its language, move it to CODE_HOME_DIR/<owner>/<lang>/<name> and optionally create and push to a remote repo",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  run,
+	}
+)
+
+func init() {
+	remote = Cmd.PersistentFlags().BoolP("remote", "r", false, "create a remote repo, add it as origin and push to it")
+	private = Cmd.PersistentFlags().BoolP("private", "p", false, "make the remote repo private")
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		dir = filepath.Join(dir, args[0])
+	}
+
+	tui.PrintProgress(0.0)
+
+	i, err := interactor.New(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("interactor.New failed: %w", err)
+	}
+	repo, err := i.Init(cmd.Context(), dir, *remote, *private)
+	if err != nil {
+		return fmt.Errorf("interactor.Init failed: %w", err)
+	}
+
+	tui.PrintProgress(1.0)
+
+	d := color.New(color.FgGreen, color.Bold)
+	if repo.Path() != dir {
+		d.Printf("Moved to %s\n", repo.Path())
+	} else {
+		d.Printf("Initialised %s\n", repo.Path())
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package ls
 
 import (
+	"errors"
 	"fmt"
 	"sgit/internal/interactor"
 
@@ -10,7 +11,9 @@ import (
 
 var (
 	langs, states, names *string
-	forks                *bool
+	output, tmpl         *string
+	sortBy, groupBy      *string
+	forks, tree          *bool
 
 	Cmd = &cobra.Command{
 		Use:   "ls",
@@ -22,12 +25,29 @@ var (
 
 func init() {
 	langs = Cmd.PersistentFlags().StringP("lang", "l", "", "comma-separated list of languages to target")
-	states = Cmd.PersistentFlags().StringP("state", "s", "", "comma-separated list of states to target")
+	states = Cmd.PersistentFlags().StringP("state", "s", "", "comma-separated list of states to target, join states with + to require all of them")
 	names = Cmd.PersistentFlags().StringP("name", "n", "", "comma-separated list of repo names to target")
 	forks = Cmd.PersistentFlags().BoolP("fork", "f", false, "target forked or non-forked repos")
+	sortBy = Cmd.PersistentFlags().String("sort", sortLang, "sort by name, lang, owner, state, pushed or size")
+	groupBy = Cmd.PersistentFlags().String("group-by", groupNone, "group by lang, owner, state or none")
+	tree = Cmd.PersistentFlags().BoolP("tree", "t", false, "show repos as a tree of owner, language and repo")
+	output = Cmd.PersistentFlags().StringP("output", "o", outputTable, "output format: table, json, jsonl, csv, tsv or template")
+	tmpl = Cmd.PersistentFlags().String("template", "", "go text/template executed per repo with --output template, e.g. '{{.Owner}}/{{.Name}} {{.State}}'")
 }
 
 func run(cmd *cobra.Command, args []string) error {
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	if err := validateSort(*sortBy, *groupBy); err != nil {
+		return err
+	}
+
+	if *tree && *output != outputTable {
+		return fmt.Errorf("--tree can't be combined with --output %s", *output)
+	}
+
 	var forksFlag *bool
 	if cmd.Flags().Changed("fork") {
 		forksFlag = forks
@@ -38,52 +58,29 @@ func run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("interactor.NewFilter failed: %w", err)
 	}
 
-	i := interactor.New()
+	i, err := interactor.New(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("interactor.New failed: %w", err)
+	}
 
+	// listing is read-only, so show what could be fetched rather than nothing
 	langToRepoStatePairs, err := i.GetRepoStates(cmd.Context(), *filter)
-	if err != nil {
+	if errors.Is(err, interactor.ErrIncompleteListing) {
+		color.New(color.FgYellow).Fprintf(cmd.ErrOrStderr(), "warning: %s\n", err)
+	} else if err != nil {
 		return fmt.Errorf("interactor.GetRepoStates failed: %w", err)
 	}
 
-	rainbow := []color.Attribute{
-		color.FgBlue, color.FgMagenta, color.FgCyan,
+	rsps := make([]interactor.RepoStatePair, 0)
+	for _, langRsps := range langToRepoStatePairs {
+		rsps = append(rsps, langRsps...)
 	}
-	z := 0
-	for lang, rsps := range langToRepoStatePairs {
-		for _, rsp := range rsps {
-			d := color.New(
-				rainbow[z%(len(rainbow)-1)],
-				color.Bold,
-			)
-			d.Print(lang + " ")
-
-			d = color.New(color.FgWhite)
-			d.Print(
-				fmt.Sprintf("%s/%s ", rsp.Owner, rsp.Name),
-			)
-
-			switch rsp.State {
-			case interactor.UpToDate:
-				d = color.New(color.FgGreen, color.Bold)
-			case interactor.UncommittedChanges:
-				d = color.New(color.FgYellow, color.Bold)
-			case interactor.NotCloned:
-				d = color.New(color.FgRed, color.Bold)
-			default:
-				d = color.New(color.FgHiMagenta, color.Bold)
-			}
-			d.Print(rsp.State.String())
-
-			if rsp.Fork {
-				d = color.New(color.FgHiCyan)
-				d.Println(" Fork")
-			} else {
-				d.Println()
-			}
 
-		}
-		z += 1
+	sortRepoStatePairs(rsps, *sortBy, *groupBy)
+	if *tree {
+		renderTree(cmd.OutOrStdout(), rsps)
+		return nil
 	}
 
-	return nil
+	return render(cmd.OutOrStdout(), *output, *tmpl, *groupBy, rsps)
 }
@@ -0,0 +1,300 @@
+package ls
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sgit/git"
+	"sgit/internal/interactor"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+const (
+	outputTable    = "table"
+	outputJson     = "json"
+	outputJsonl    = "jsonl"
+	outputCsv      = "csv"
+	outputTsv      = "tsv"
+	outputTemplate = "template"
+)
+
+type (
+	// record is the machine readable form of a RepoStatePair, it is also
+	// what --template is executed against.
+	record struct {
+		Host               string        `json:"host"`
+		Profile            string        `json:"profile"`
+		Owner              string        `json:"owner"`
+		Name               string        `json:"name"`
+		Language           string        `json:"language"`
+		ExpectedLanguage   string        `json:"expected_language"`
+		State              string        `json:"state"`
+		States             []string      `json:"states"`
+		Branch             string        `json:"branch"`
+		Path               string        `json:"path"`
+		URL                string        `json:"url"`
+		UpstreamURL        string        `json:"upstream_url"`
+		Fork               bool          `json:"fork"`
+		GitRepo            bool          `json:"git_repo"`
+		UncommittedChanges bool          `json:"uncommitted_changes"`
+		MergeConflicts     bool          `json:"merge_conflicts"`
+		Ahead              int           `json:"ahead"`
+		Behind             int           `json:"behind"`
+		Stashes            int           `json:"stashes"`
+		Remotes            []git.Remote  `json:"remotes"`
+		LastCommit         *commitRecord `json:"last_commit"`
+		PushedAt           *time.Time    `json:"pushed_at"` // nil for repos without a remote
+		Size               int64         `json:"size"`      // in kilobytes, 0 for repos without a remote
+	}
+
+	commitRecord struct {
+		Hash    string    `json:"hash"`
+		Author  string    `json:"author"`
+		Subject string    `json:"subject"`
+		Date    time.Time `json:"date"`
+	}
+)
+
+func newRecord(rsp interactor.RepoStatePair) record {
+	states := make([]string, 0)
+	for _, s := range rsp.State.Flags() {
+		states = append(states, s.String())
+	}
+
+	r := record{
+		Host:               rsp.Host,
+		Profile:            rsp.Profile,
+		Owner:              rsp.Owner,
+		Name:               rsp.Name,
+		Language:           rsp.Language,
+		ExpectedLanguage:   rsp.ExpectedLanguage,
+		State:              rsp.State.String(),
+		States:             states,
+		Branch:             rsp.Branch,
+		URL:                rsp.URL,
+		UpstreamURL:        rsp.UpstreamURL,
+		Fork:               rsp.Fork,
+		GitRepo:            rsp.GitRepo,
+		UncommittedChanges: rsp.UncommitedChanges,
+		MergeConflicts:     rsp.MergeConflicts,
+		Ahead:              rsp.Status.Ahead,
+		Behind:             rsp.Status.Behind,
+		Stashes:            rsp.Status.Stashes,
+		Remotes:            rsp.Remotes,
+		Size:               rsp.Size,
+	}
+
+	if !rsp.PushedAt.IsZero() {
+		pushedAt := rsp.PushedAt
+		r.PushedAt = &pushedAt
+	}
+
+	if !rsp.State.Has(interactor.NotCloned) {
+		r.Path = rsp.Path()
+	}
+
+	if c := rsp.LastCommit; c != nil {
+		r.LastCommit = &commitRecord{c.Hash, c.Author, c.Subject, c.Date}
+	}
+
+	return r
+}
+
+func render(w io.Writer, format, tmpl, groupBy string, rsps []interactor.RepoStatePair) error {
+	records := make([]record, 0)
+	for _, rsp := range rsps {
+		records = append(records, newRecord(rsp))
+	}
+
+	switch format {
+	case outputTable:
+		renderTable(w, groupBy, rsps)
+		return nil
+	case outputJson:
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(records)
+	case outputJsonl:
+		e := json.NewEncoder(w)
+		for _, r := range records {
+			if err := e.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case outputCsv:
+		return renderDelimited(w, ',', records)
+	case outputTsv:
+		return renderDelimited(w, '\t', records)
+	case outputTemplate:
+		return renderTemplate(w, tmpl, records)
+	}
+
+	return validateOutput(format)
+}
+
+func validateOutput(format string) error {
+	outputs := []string{outputTable, outputJson, outputJsonl, outputCsv, outputTsv, outputTemplate}
+	for _, o := range outputs {
+		if o == format {
+			return nil
+		}
+	}
+
+	return fmt.Errorf(
+		"invalid --output \"%s\", valid outputs: %s",
+		format,
+		strings.Join(outputs, " "),
+	)
+}
+
+func renderDelimited(w io.Writer, delimiter rune, records []record) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = delimiter
+
+	header := []string{
+		"host", "profile", "owner", "name", "language", "expected_language",
+		"state", "branch", "path", "url", "upstream_url", "fork", "git_repo",
+		"uncommitted_changes", "merge_conflicts", "ahead", "behind", "stashes",
+		"last_commit", "pushed_at", "size",
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		lastCommit := ""
+		if r.LastCommit != nil {
+			lastCommit = r.LastCommit.Hash
+		}
+
+		pushedAt := ""
+		if r.PushedAt != nil {
+			pushedAt = r.PushedAt.Format(time.RFC3339)
+		}
+
+		row := []string{
+			r.Host, r.Profile, r.Owner, r.Name, r.Language, r.ExpectedLanguage,
+			r.State, r.Branch, r.Path, r.URL, r.UpstreamURL,
+			strconv.FormatBool(r.Fork), strconv.FormatBool(r.GitRepo),
+			strconv.FormatBool(r.UncommittedChanges), strconv.FormatBool(r.MergeConflicts),
+			strconv.Itoa(r.Ahead), strconv.Itoa(r.Behind), strconv.Itoa(r.Stashes),
+			lastCommit, pushedAt, strconv.FormatInt(r.Size, 10),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// renderTemplate executes tmpl once per repo, each on its own line.
+func renderTemplate(w io.Writer, tmpl string, records []record) error {
+	if tmpl == "" {
+		return fmt.Errorf("--output %s requires --template", outputTemplate)
+	}
+
+	t, err := template.New("ls").Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("invalid --template: %w", err)
+	}
+
+	for _, r := range records {
+		if err := t.Execute(w, r); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+
+	return nil
+}
+
+// renderTable prints the coloured human readable listing, with a header
+// above each group unless groupBy is none. Colour is turned off by
+// fatih/color when stdout isn't a terminal.
+func renderTable(w io.Writer, groupBy string, rsps []interactor.RepoStatePair) {
+	rainbow := []color.Attribute{
+		color.FgBlue, color.FgMagenta, color.FgCyan,
+	}
+	langIndex := make(map[string]int, 0)
+	for i, rsp := range rsps {
+		if groupBy != groupNone {
+			if g := group(rsp, groupBy); i == 0 || g != group(rsps[i-1], groupBy) {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				color.New(color.Bold, color.Underline).Fprintln(w, g)
+			}
+		}
+
+		z, ok := langIndex[rsp.Language]
+		if !ok {
+			z = len(langIndex)
+			langIndex[rsp.Language] = z
+		}
+
+		d := color.New(
+			rainbow[z%(len(rainbow)-1)],
+			color.Bold,
+		)
+		d.Fprint(w, rsp.Language+" ")
+
+		d = color.New(color.FgWhite)
+		d.Fprint(w,
+			fmt.Sprintf("%s/%s ", rsp.Owner, rsp.Name),
+		)
+
+		if rsp.Branch != "" {
+			d = color.New(color.FgHiWhite)
+			d.Fprint(w, fmt.Sprintf("(%s) ", rsp.Branch))
+		}
+
+		for n, state := range rsp.State.Flags() {
+			if n > 0 {
+				fmt.Fprint(w, " ")
+			}
+			stateColor(state).Fprint(w, state.String())
+		}
+
+		if rsp.Fork {
+			d = color.New(color.FgHiCyan)
+			d.Fprint(w, " Fork")
+			if u, err := git.ParseUrl(rsp.UpstreamURL); err == nil {
+				d.Fprint(w, fmt.Sprintf(" of %s/%s", u.Owner, u.Name))
+			}
+		}
+
+		if rsp.LastCommit != nil {
+			d = color.New(color.FgHiBlack)
+			d.Fprint(w, fmt.Sprintf(" %s %s", rsp.LastCommit.ShortHash(), rsp.LastCommit.Subject))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func stateColor(s interactor.State) *color.Color {
+	switch s {
+	case interactor.UpToDate:
+		return color.New(color.FgGreen, color.Bold)
+	case interactor.UncommittedChanges:
+		return color.New(color.FgYellow, color.Bold)
+	case interactor.NotCloned, interactor.MergeConflicts:
+		return color.New(color.FgRed, color.Bold)
+	case interactor.Ahead, interactor.Behind:
+		return color.New(color.FgCyan, color.Bold)
+	case interactor.Diverged, interactor.DetachedHead:
+		return color.New(color.FgHiRed, color.Bold)
+	case interactor.StashPresent:
+		return color.New(color.FgHiBlack, color.Bold)
+	default:
+		return color.New(color.FgHiMagenta, color.Bold)
+	}
+}
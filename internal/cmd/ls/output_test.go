@@ -0,0 +1,84 @@
+package ls
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"sgit/internal/interactor"
+	"testing"
+	"time"
+)
+
+func testRepoStatePairs() []interactor.RepoStatePair {
+	pushed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return []interactor.RepoStatePair{
+		{
+			Repo: interactor.Repo{
+				Name: "remote", Owner: "me", Host: "github.com", Language: "go",
+				ExpectedLanguage: "rust", Profile: "personal", PushedAt: pushed, Size: 42,
+			},
+			State: interactor.NotCloned,
+		},
+		{
+			Repo:  interactor.Repo{Name: "local", Owner: "me", Host: "github.com", Language: "unknown", GitRepo: true},
+			State: interactor.NoRemoteRepo,
+		},
+	}
+}
+
+func TestRenderDelimited(t *testing.T) {
+	var b bytes.Buffer
+	if err := render(&b, outputCsv, "", groupNone, testRepoStatePairs()); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	rows, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatalf("output isn't valid csv: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want a header and 2 repos", len(rows))
+	}
+
+	got := make(map[string]string, 0)
+	for i, column := range rows[0] {
+		got[column] = rows[1][i]
+	}
+
+	want := map[string]string{
+		"profile":           "personal",
+		"expected_language": "rust",
+		"pushed_at":         "2024-05-01T12:00:00Z",
+		"size":              "42",
+		"git_repo":          "false",
+	}
+	for column, v := range want {
+		if got[column] != v {
+			t.Errorf("%s = %q, want %q", column, got[column], v)
+		}
+	}
+
+	if pushedAt := rows[2][len(rows[0])-2]; pushedAt != "" {
+		t.Errorf("pushed_at of a repo without a remote = %q, want empty", pushedAt)
+	}
+}
+
+func TestRenderJson(t *testing.T) {
+	var b bytes.Buffer
+	if err := render(&b, outputJson, "", groupNone, testRepoStatePairs()); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	var records []map[string]any
+	if err := json.Unmarshal(b.Bytes(), &records); err != nil {
+		t.Fatalf("output isn't valid json: %v", err)
+	}
+
+	r := records[0]
+	if r["pushed_at"] != "2024-05-01T12:00:00Z" || r["size"] != 42.0 || r["expected_language"] != "rust" {
+		t.Errorf("record = %v, want pushed_at, size and expected_language set", r)
+	}
+	if records[1]["pushed_at"] != nil {
+		t.Errorf("pushed_at of a repo without a remote = %v, want null", records[1]["pushed_at"])
+	}
+}
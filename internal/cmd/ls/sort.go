@@ -0,0 +1,97 @@
+package ls
+
+import (
+	"fmt"
+	"sgit/internal/interactor"
+	"sort"
+	"strings"
+)
+
+const (
+	sortName   = "name"
+	sortLang   = "lang"
+	sortOwner  = "owner"
+	sortState  = "state"
+	sortPushed = "pushed"
+	sortSize   = "size"
+
+	groupLang  = "lang"
+	groupOwner = "owner"
+	groupState = "state"
+	groupNone  = "none"
+)
+
+var (
+	sortKeys  = []string{sortName, sortLang, sortOwner, sortState, sortPushed, sortSize}
+	groupKeys = []string{groupLang, groupOwner, groupState, groupNone}
+)
+
+func validateSort(sortBy, groupBy string) error {
+	if !containsKey(sortKeys, sortBy) {
+		return fmt.Errorf("invalid --sort \"%s\", valid keys: %s", sortBy, strings.Join(sortKeys, " "))
+	}
+
+	if !containsKey(groupKeys, groupBy) {
+		return fmt.Errorf("invalid --group-by \"%s\", valid keys: %s", groupBy, strings.Join(groupKeys, " "))
+	}
+
+	return nil
+}
+
+// group returns the value rsp is grouped under, "" when not grouping.
+func group(rsp interactor.RepoStatePair, groupBy string) string {
+	switch groupBy {
+	case groupLang:
+		return rsp.Language
+	case groupOwner:
+		return rsp.Owner
+	case groupState:
+		return rsp.State.String()
+	}
+	return ""
+}
+
+// sortRepoStatePairs orders rsps by group, then by the sort key, then by full
+// name so the output is identical between runs. pushed and size sort most
+// recent and largest first.
+func sortRepoStatePairs(rsps []interactor.RepoStatePair, sortBy, groupBy string) {
+	less := func(a, b interactor.RepoStatePair) (bool, bool) {
+		switch sortBy {
+		case sortName:
+			return a.Name < b.Name, a.Name != b.Name
+		case sortLang:
+			return a.Language < b.Language, a.Language != b.Language
+		case sortOwner:
+			return a.Owner < b.Owner, a.Owner != b.Owner
+		case sortState:
+			return a.State < b.State, a.State != b.State
+		case sortPushed:
+			return a.PushedAt.After(b.PushedAt), !a.PushedAt.Equal(b.PushedAt)
+		case sortSize:
+			return a.Size > b.Size, a.Size != b.Size
+		}
+		return false, false
+	}
+
+	sort.SliceStable(rsps, func(i, j int) bool {
+		a, b := rsps[i], rsps[j]
+		if ga, gb := group(a, groupBy), group(b, groupBy); ga != gb {
+			return ga < gb
+		}
+
+		if l, decided := less(a, b); decided {
+			return l
+		}
+
+		return a.FullName() < b.FullName()
+	})
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package ls
+
+import (
+	"fmt"
+	"io"
+	"sgit/github"
+	"sgit/internal/config"
+	"sgit/internal/interactor"
+	"sort"
+
+	"github.com/fatih/color"
+)
+
+// node is a directory in the tree view, leaves hold the repos directly
+// beneath it. Only directories containing at least one repo are created, so
+// branches emptied by filters never show up.
+type node struct {
+	name     string
+	children map[string]*node
+	repos    []interactor.RepoStatePair
+	count    int
+}
+
+func newNode(name string) *node {
+	return &node{name: name, children: make(map[string]*node, 0)}
+}
+
+func (n *node) child(name string) *node {
+	c, ok := n.children[name]
+	if !ok {
+		c = newNode(name)
+		n.children[name] = c
+	}
+	return c
+}
+
+// renderTree prints rsps as the <host>/<owner>/<lang>/<name> layout beneath
+// CODE_HOME_DIR, github.com repos sitting directly under the root. Repos keep
+// the order of rsps within their language, directories are alphabetical.
+func renderTree(w io.Writer, rsps []interactor.RepoStatePair) {
+	root := newNode(config.Get(config.BaseDir))
+	for _, rsp := range rsps {
+		n := root
+		n.count += 1
+		if rsp.Host != "" && rsp.Host != github.DefaultHost {
+			n = n.child(rsp.Host)
+			n.count += 1
+		}
+
+		n = n.child(rsp.Owner)
+		n.count += 1
+		n = n.child(rsp.Language)
+		n.count += 1
+		n.repos = append(n.repos, rsp)
+	}
+
+	color.New(color.Bold).Fprintf(w, "%s ", root.name)
+	fmt.Fprintf(w, "(%d)\n", root.count)
+	printChildren(w, root, "")
+}
+
+func printChildren(w io.Writer, n *node, prefix string) {
+	names := make([]string, 0)
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	total := len(names) + len(n.repos)
+	i := 0
+	branch := func() (string, string) {
+		i += 1
+		if i == total {
+			return "└── ", "    "
+		}
+		return "├── ", "│   "
+	}
+
+	for _, name := range names {
+		c := n.children[name]
+		b, indent := branch()
+		fmt.Fprint(w, prefix+b)
+		color.New(color.FgBlue, color.Bold).Fprintf(w, "%s ", c.name)
+		fmt.Fprintf(w, "(%d)\n", c.count)
+		printChildren(w, c, prefix+indent)
+	}
+
+	for _, rsp := range n.repos {
+		b, _ := branch()
+		fmt.Fprint(w, prefix+b)
+		color.New(color.FgWhite).Fprint(w, rsp.Name)
+		for _, state := range rsp.State.Flags() {
+			fmt.Fprint(w, " ")
+			stateColor(state).Fprintf(w, "[%s]", state.String())
+		}
+		fmt.Fprintln(w)
+	}
+}
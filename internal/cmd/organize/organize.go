@@ -0,0 +1,149 @@
+package organize
+
+import (
+	"errors"
+	"fmt"
+	"sgit/internal/interactor"
+	"sgit/internal/tui"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	langs, names *string
+	forks, yes   *bool
+	dryRun       *bool
+
+	Cmd = &cobra.Command{
+		Use:   "organize",
+		Short: "move repos into the language directory their remote reports",
+		Long:  "move repos filed under a language other than the one their remote reports into CODE_HOME_DIR/<owner>/<lang>/<name>, showing the moves and asking before making them",
+		RunE:  run,
+	}
+)
+
+func init() {
+	langs = Cmd.PersistentFlags().StringP("lang", "l", "", "comma-separated list of languages to target")
+	names = Cmd.PersistentFlags().StringP("name", "n", "", "comma-separated list of repo names to target")
+	forks = Cmd.PersistentFlags().BoolP("fork", "f", false, "target forked or non-forked repos")
+	yes = Cmd.PersistentFlags().BoolP("yes", "y", false, "move without asking for confirmation")
+	dryRun = Cmd.PersistentFlags().Bool("dry-run", false, "show the moves without making them")
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	var forksFlag *bool
+	if cmd.Flags().Changed("fork") {
+		forksFlag = forks
+	}
+
+	filter, err := interactor.NewFilter(*langs, interactor.IncorrectLanguageParentDirectory.String(), *names, forksFlag)
+	if err != nil {
+		return fmt.Errorf("interactor.NewFilter failed: %w", err)
+	}
+
+	i, err := interactor.New(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("interactor.New failed: %w", err)
+	}
+
+	langToRepoStatePairs, err := i.GetRepoStates(cmd.Context(), *filter)
+	if err != nil {
+		return fmt.Errorf("interactor.GetRepoStates failed: %w", err)
+	}
+
+	rsps := make([]interactor.RepoStatePair, 0)
+	for _, langRsps := range langToRepoStatePairs {
+		rsps = append(rsps, langRsps...)
+	}
+
+	moves, err := i.PlanMoves(rsps)
+	if err != nil {
+		return fmt.Errorf("interactor.PlanMoves failed: %w", err)
+	}
+
+	if len(moves) == 0 {
+		fmt.Println("Every repo is in the right place")
+		return nil
+	}
+
+	allowed := printDiff(moves)
+	if *dryRun {
+		return nil
+	} else if allowed == 0 {
+		return skipped(moves)
+	}
+
+	if err := showPrompt(allowed); err != nil {
+		return err
+	}
+
+	if err := i.ApplyMoves(moves); err != nil {
+		return fmt.Errorf("interactor.ApplyMoves failed: %w", err)
+	}
+
+	d := color.New(color.FgGreen, color.Bold)
+	if allowed == 1 {
+		d.Println("Moved 1 repo")
+	} else {
+		d.Printf("Moved %d repos\n", allowed)
+	}
+	return skipped(moves)
+}
+
+// printDiff shows each move as a removed and an added path, returning how
+// many moves can be made.
+func printDiff(moves []interactor.Move) int {
+	del := color.New(color.FgRed)
+	add := color.New(color.FgGreen)
+	warn := color.New(color.FgYellow)
+
+	allowed := 0
+	for _, m := range moves {
+		del.Printf("- %s\n", m.From)
+		add.Printf("+ %s\n", m.To)
+		if m.Collision != "" {
+			warn.Printf("! skipped: %s\n", m.Collision)
+		} else {
+			allowed += 1
+		}
+	}
+
+	return allowed
+}
+
+func skipped(moves []interactor.Move) error {
+	n := 0
+	for _, m := range moves {
+		if m.Collision != "" {
+			n += 1
+		}
+	}
+
+	if n == 1 {
+		return errors.New("1 move skipped because of a collision, move or rename the destination and rerun")
+	} else if n > 1 {
+		return fmt.Errorf("%d moves skipped because of collisions, move or rename the destinations and rerun", n)
+	}
+	return nil
+}
+
+func showPrompt(n int) error {
+	if *yes {
+		return nil
+	}
+
+	msg := "You're about to move 1 repo"
+	if n > 1 {
+		msg = fmt.Sprintf("You're about to move %d repos", n)
+	}
+
+	proceed, err := tui.Confirm(msg + ", would you like to proceed?")
+	if err != nil {
+		return err
+	} else if !proceed {
+		return tui.ErrDeclined
+	}
+
+	return nil
+}
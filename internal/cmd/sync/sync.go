@@ -0,0 +1,131 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sgit/internal/interactor"
+	"sgit/internal/tui"
+	"sort"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	langs, states, names *string
+	forks                *bool
+	concurrency          *int
+
+	Cmd = &cobra.Command{
+		Use:   "sync",
+		Short: "pull repos that are behind and push repos that are ahead",
+		Long:  "fetch every repo, fast-forward those that are behind, push those that are ahead and report the ones that need attention",
+		RunE:  run,
+	}
+)
+
+func init() {
+	langs = Cmd.PersistentFlags().StringP("lang", "l", "", "comma-separated list of languages to target")
+	states = Cmd.PersistentFlags().StringP("state", "s", "", "comma-separated list of states to target, join states with + to require all of them")
+	names = Cmd.PersistentFlags().StringP("name", "n", "", "comma-separated list of repo names to target")
+	forks = Cmd.PersistentFlags().BoolP("fork", "f", false, "target forked or non-forked repos")
+	concurrency = Cmd.PersistentFlags().IntP("concurrency", "c", 4, "number of repos to sync at once")
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	var forksFlag *bool
+	if cmd.Flags().Changed("fork") {
+		forksFlag = forks
+	}
+
+	filter, err := interactor.NewFilter(*langs, *states, *names, forksFlag)
+	if err != nil {
+		return fmt.Errorf("interactor.NewFilter failed: %w", err)
+	}
+
+	if *concurrency < 1 {
+		return errors.New("--concurrency must be at least 1")
+	}
+
+	i, err := interactor.New(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("interactor.New failed: %w", err)
+	}
+
+	langToRepoStatePairs, err := i.GetRepoStates(cmd.Context(), *filter)
+	if err != nil {
+		return fmt.Errorf("interactor.GetRepoStates failed: %w", err)
+	}
+
+	repos := make([]interactor.Repo, 0)
+	for _, rsps := range langToRepoStatePairs {
+		for _, rsp := range rsps {
+			if rsp.State.Has(interactor.NotCloned) || rsp.State.Has(interactor.NotGitRepo) {
+				continue
+			}
+			repos = append(repos, rsp.Repo)
+		}
+	}
+
+	if len(repos) == 0 {
+		return nil
+	}
+
+	type result struct {
+		interactor.Repo
+		interactor.SyncResult
+		err error
+	}
+
+	tui.PrintProgress(0.0)
+	sem := make(chan struct{}, *concurrency)
+	results := make(chan result, len(repos))
+	var wg sync.WaitGroup
+	for _, repo := range repos {
+		wg.Add(1)
+		go func(r interactor.Repo) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			sr, err := i.Sync(cmd.Context(), r)
+			results <- result{r, sr, err}
+		}(repo)
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	rv := make([]result, 0)
+	for r := range results {
+		rv = append(rv, r)
+		tui.PrintProgress(float64(len(rv)) / float64(len(repos)))
+	}
+
+	sort.Slice(rv, func(a, b int) bool {
+		return rv[a].FullName() < rv[b].FullName()
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPO\tRESULT\tERROR")
+	failed := 0
+	for _, r := range rv {
+		msg := ""
+		if r.err != nil {
+			failed += 1
+			msg = r.err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.FullName(), r.SyncResult, msg)
+	}
+	w.Flush()
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d repos failed to sync", failed, len(rv))
+	}
+
+	return nil
+}
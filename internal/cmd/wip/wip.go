@@ -0,0 +1,154 @@
+package wip
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sgit/git"
+	"sgit/internal/interactor"
+	"sgit/internal/tui"
+	"sort"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	langs, names, message *string
+	forks, yes            *bool
+	maxFileSize           *int64
+
+	Cmd = &cobra.Command{
+		Use:   "wip",
+		Short: "push uncommitted work to wip branches",
+		Long:  "snapshot uncommitted work in every dirty repo and push it to a new wip/<hostname>/<branch>/<date>-<time>-<hash> branch, leaving the checked out branch untouched",
+		RunE:  run,
+	}
+)
+
+func init() {
+	langs = Cmd.PersistentFlags().StringP("lang", "l", "", "comma-separated list of languages to target")
+	names = Cmd.PersistentFlags().StringP("name", "n", "", "comma-separated list of repo names to target")
+	forks = Cmd.PersistentFlags().BoolP("fork", "f", false, "target forked or non-forked repos")
+	message = Cmd.PersistentFlags().StringP("message", "m", git.DefaultWipMessage, "commit message template, fields: .Branch .Host .Date")
+	yes = Cmd.PersistentFlags().BoolP("yes", "y", false, "push without asking for confirmation")
+	maxFileSize = Cmd.PersistentFlags().Int64("max-file-size", git.DefaultWipMaxFileSize, "refuse to push repos containing changed files larger than this many bytes, 0 to disable")
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	var forksFlag *bool
+	if cmd.Flags().Changed("fork") {
+		forksFlag = forks
+	}
+
+	filter, err := interactor.NewFilter(*langs, interactor.UncommittedChanges.String(), *names, forksFlag)
+	if err != nil {
+		return fmt.Errorf("interactor.NewFilter failed: %w", err)
+	}
+
+	i, err := interactor.New(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("interactor.New failed: %w", err)
+	}
+
+	langToRepoStatePairs, err := i.GetRepoStates(cmd.Context(), *filter)
+	if err != nil {
+		return fmt.Errorf("interactor.GetRepoStates failed: %w", err)
+	}
+
+	repos := make([]interactor.Repo, 0)
+	for _, rsps := range langToRepoStatePairs {
+		for _, rsp := range rsps {
+			if !rsp.State.Has(interactor.NoRemoteRepo) {
+				repos = append(repos, rsp.Repo)
+			}
+		}
+	}
+
+	if len(repos) == 0 {
+		return nil
+	}
+
+	if err := showPrompt(repos); err != nil {
+		return err
+	}
+
+	opts := git.WipOptions{
+		MaxFileSize: *maxFileSize,
+		Message:     *message,
+	}
+
+	type result struct {
+		interactor.Repo
+		branch string
+		err    error
+	}
+
+	tui.PrintProgress(0.0)
+	sem := make(chan struct{}, i.Concurrency())
+	results := make(chan result, len(repos))
+	var wg sync.WaitGroup
+	for _, repo := range repos {
+		wg.Add(1)
+		go func(r interactor.Repo) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			branch, err := i.Wip(cmd.Context(), r, opts)
+			results <- result{r, branch, err}
+		}(repo)
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	rv := make([]result, 0)
+	for r := range results {
+		rv = append(rv, r)
+		tui.PrintProgress(float64(len(rv)) / float64(len(repos)))
+	}
+
+	sort.Slice(rv, func(a, b int) bool {
+		return rv[a].FullName() < rv[b].FullName()
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPO\tBRANCH\tERROR")
+	errs := make([]error, 0)
+	for _, r := range rv {
+		msg := ""
+		if r.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.FullName(), r.err))
+			msg = r.err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.FullName(), r.branch, msg)
+	}
+	w.Flush()
+
+	return errors.Join(errs...)
+}
+
+func showPrompt(repos []interactor.Repo) error {
+	tui.Output(repos)
+	if *yes {
+		return nil
+	}
+
+	msg := "You're about to push uncommitted work in 1 repo"
+	if len(repos) > 1 {
+		msg = fmt.Sprintf("You're about to push uncommitted work in %d repos", len(repos))
+	}
+
+	proceed, err := tui.Confirm(msg + ", would you like to proceed?")
+	if err != nil {
+		return err
+	} else if !proceed {
+		return tui.ErrDeclined
+	}
+
+	return nil
+}
@@ -0,0 +1,645 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sgit/github"
+	"sgit/provider"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	BaseDir             = "base_dir"
+	Layout              = "layout"
+	Provider            = "provider"
+	Concurrency         = "concurrency"
+	Username            = "username"
+	FilterLang          = "filter.lang"
+	FilterState         = "filter.state"
+	FilterName          = "filter.name"
+	GithubToken         = "github.token"
+	GithubUsername      = "github.username"
+	GithubAffiliations  = "github.affiliations"
+	GithubOrgs          = "github.orgs"
+	GithubApiUrl        = "github.api_url"
+	GithubHost          = "github.host"
+	GithubRequestBudget = "github.request_budget"
+	GitlabToken         = "gitlab.token"
+	GitlabUrl           = "gitlab.url"
+	GiteaToken          = "gitea.token"
+	GiteaUrl            = "gitea.url"
+	Profiles            = "profiles"
+)
+
+// Tables whose keys are chosen by the user, see Table.
+const (
+	// Languages aliases a language to another, e.g. "vim script" = "vim".
+	Languages = "languages"
+	// Repos pins a repo, "<owner>/<name>", to a language directory.
+	Repos = "repos"
+)
+
+// Fields of a [profile.<name>] table, see ProfileKey.
+const (
+	ProfileProvider = "provider"
+	ProfileToken    = "token"
+	ProfileUsername = "username"
+	ProfileUrl      = "url"
+	ProfileHost     = "host"
+	ProfileSshHost  = "ssh_host"
+	// GitHub only, they default to the top-level github.* settings.
+	ProfileAffiliations  = "affiliations"
+	ProfileOrgs          = "orgs"
+	ProfileRequestBudget = "request_budget"
+
+	profilePrefix = "profile."
+)
+
+const (
+	DefaultLayout      = "owner/lang/name"
+	DefaultConcurrency = "4"
+
+	sourceFlag    = "flag"
+	sourceFile    = "file"
+	sourceEnv     = "env"
+	sourceDefault = "default"
+	redacted      = "********"
+
+	configEnv            = "SGIT_CONFIG"
+	configFile           = "config.toml"
+	configDirPermissions = 0o700
+	filePermissions      = 0o600
+)
+
+// Key is a setting sgit understands, env names the environment variable that
+// overrides the config file.
+type Key struct {
+	Name, Env, Default, Usage string
+	Secret                    bool
+	validate                  func(string) error
+}
+
+var Keys = []Key{
+	{Name: BaseDir, Env: "CODE_HOME_DIR", Usage: "root directory repos are cloned into", validate: validateDir},
+	{Name: Layout, Env: "SGIT_LAYOUT", Default: DefaultLayout, Usage: "directory layout beneath base_dir, owner/lang/name is the only one supported", validate: validateLayout},
+	{Name: Provider, Env: "SGIT_PROVIDER", Default: provider.Github, Usage: "hosting provider: github, gitlab, gitea or forgejo", validate: validateProvider},
+	{Name: Concurrency, Env: "SGIT_CONCURRENCY", Default: DefaultConcurrency, Usage: "number of repos worked on at once", validate: validatePositiveInt},
+	{Name: Username, Env: "SGIT_USERNAME", Usage: "account name on the provider, looked up from the token when unset"},
+	{Name: FilterLang, Env: "SGIT_FILTER_LANG", Usage: "default --lang filter for ls, sync and organize"},
+	{Name: FilterState, Env: "SGIT_FILTER_STATE", Usage: "default --state filter for ls, sync and organize"},
+	{Name: FilterName, Env: "SGIT_FILTER_NAME", Usage: "default --name filter for ls, sync and organize"},
+	{Name: GithubToken, Env: "GITHUB_TOKEN", Usage: "GitHub API token", Secret: true},
+	{Name: GithubUsername, Env: "GITHUB_USERNAME", Usage: "GitHub username, used when username is unset"},
+	{Name: GithubAffiliations, Env: "GITHUB_AFFILIATIONS", Default: "owner", Usage: "comma-separated affiliations to list: owner, collaborator, organization_member", validate: validateAffiliations},
+	{Name: GithubOrgs, Env: "GITHUB_ORGS", Usage: "comma-separated orgs whose repos are listed in full"},
+	{Name: GithubApiUrl, Env: "GITHUB_API_URL", Usage: "GitHub Enterprise Server api url"},
+	{Name: GithubHost, Env: "GITHUB_HOST", Usage: "web/ssh host, defaults to the host of github.api_url"},
+	{Name: GithubRequestBudget, Env: "GITHUB_REQUEST_BUDGET", Usage: "max time a GitHub API call may spend retrying", validate: validateDuration},
+	{Name: GitlabToken, Env: "GITLAB_TOKEN", Usage: "GitLab API token", Secret: true},
+	{Name: GitlabUrl, Env: "GITLAB_URL", Usage: "GitLab instance url"},
+	{Name: GiteaToken, Env: "GITEA_TOKEN", Usage: "Gitea/Forgejo API token", Secret: true},
+	{Name: GiteaUrl, Env: "GITEA_URL", Usage: "Gitea/Forgejo instance url"},
+	{Name: Profiles, Env: "SGIT_PROFILES", Usage: "comma-separated profiles to use, all of them when unset", validate: validateProfiles},
+}
+
+// ProfileFields are the settings of a profile, their names are relative to
+// the profile's table.
+var ProfileFields = []Key{
+	{Name: ProfileProvider, Usage: "hosting provider, defaults to the provider setting", validate: validateProvider},
+	{Name: ProfileToken, Usage: "API token, looked up by host like the top-level token when unset", Secret: true},
+	{Name: ProfileUsername, Usage: "account name, looked up from the token when unset"},
+	{Name: ProfileUrl, Usage: "API url for GitHub Enterprise, or the GitLab/Gitea instance url"},
+	{Name: ProfileHost, Usage: "web/ssh host, defaults to the host of url"},
+	{Name: ProfileSshHost, Usage: "ssh host alias to clone through, e.g. github-work from ~/.ssh/config"},
+	{Name: ProfileAffiliations, Usage: "GitHub affiliations to list, defaults to github.affiliations", validate: validateAffiliations},
+	{Name: ProfileOrgs, Usage: "GitHub orgs whose repos are listed in full, defaults to github.orgs"},
+	{Name: ProfileRequestBudget, Usage: "max time a GitHub API call may spend retrying, defaults to github.request_budget", validate: validateDuration},
+}
+
+// ProfileKey is the name of a profile's field, profile.<profile>.<field>.
+func ProfileKey(profile, field string) string {
+	return profilePrefix + profile + "." + field
+}
+
+// ProfileNames returns the profiles defined in the config file, sorted.
+func ProfileNames() []string {
+	c, err := Load()
+	if err != nil {
+		return []string{}
+	}
+
+	names := make(map[string]struct{}, 0)
+	for name := range c.values {
+		if profile, _, ok := splitProfileKey(name); ok {
+			names[profile] = struct{}{}
+		}
+	}
+
+	rv := make([]string, 0)
+	for name := range names {
+		rv = append(rv, name)
+	}
+	sort.Strings(rv)
+	return rv
+}
+
+// Table returns the keys and values of a table from the config file, e.g.
+// Table(Languages).
+func Table(table string) map[string]string {
+	rv := make(map[string]string, 0)
+	c, err := Load()
+	if err != nil {
+		return rv
+	}
+
+	for name, v := range c.values {
+		if t, key := splitKey(name); t == table {
+			rv[key] = v
+		}
+	}
+	return rv
+}
+
+func splitProfileKey(name string) (string, string, bool) {
+	if !strings.HasPrefix(name, profilePrefix) {
+		return "", "", false
+	}
+
+	profile, field := splitKey(strings.TrimPrefix(name, profilePrefix))
+	return profile, field, profile != ""
+}
+
+func lookupKey(name string) (Key, error) {
+	for _, k := range Keys {
+		if k.Name == name {
+			return k, nil
+		}
+	}
+
+	if table, key := splitKey(name); (table == Languages || table == Repos) && key != "" {
+		return Key{Name: name, Usage: "language directory"}, nil
+	}
+
+	if profile, field, ok := splitProfileKey(name); ok && !strings.Contains(profile, ".") {
+		for _, k := range ProfileFields {
+			if k.Name == field {
+				k.Name = name
+				return k, nil
+			}
+		}
+	}
+	return Key{}, fmt.Errorf("unknown config key %q, see sgit config list", name)
+}
+
+// Check reports whether name is a known setting.
+func Check(name string) error {
+	_, err := lookupKey(name)
+	return err
+}
+
+// Config is the parsed config file, env vars and defaults are layered on top
+// by Get rather than being stored.
+type Config struct {
+	path   string
+	values map[string]string
+}
+
+var (
+	loaded    *Config
+	loadErr   error
+	once      sync.Once
+	overrides = make(map[string]string, 0)
+)
+
+// Override sets name for the rest of the process, taking precedence over the
+// environment and config file. Commands use it for flags that map to
+// settings.
+func Override(name, value string) {
+	overrides[name] = value
+}
+
+// Load reads the config file once, a missing file is an empty config.
+func Load() (*Config, error) {
+	once.Do(func() {
+		loaded, loadErr = Read(Path())
+	})
+	return loaded, loadErr
+}
+
+// Get resolves name from overrides, the environment, the config file and then
+// its default, in that order. A config file that failed to load is ignored
+// here, commands surface that error through Validate.
+func Get(name string) string {
+	v, _ := Resolve(name)
+	return v
+}
+
+// Resolve is Get that also returns where the value came from.
+func Resolve(name string) (string, string) {
+	k, err := lookupKey(name)
+	if err != nil {
+		return "", ""
+	}
+
+	if v, ok := overrides[name]; ok {
+		return v, sourceFlag
+	}
+
+	if v, ok := os.LookupEnv(k.Env); ok {
+		return v, sourceEnv
+	}
+
+	if c, err := Load(); err == nil {
+		if v, ok := c.values[name]; ok {
+			return v, sourceFile
+		}
+	}
+
+	return k.Default, sourceDefault
+}
+
+// Path is $SGIT_CONFIG, or config.toml in $XDG_CONFIG_HOME/sgit falling back
+// to ~/.config/sgit.
+func Path() string {
+	if p := os.Getenv(configEnv); p != "" {
+		return p
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join(".config", "sgit", configFile)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "sgit", configFile)
+}
+
+// Validate checks every resolved setting and that the ones without a fallback
+// are set, reporting all problems at once.
+func Validate() error {
+	c, err := Load()
+	if err != nil {
+		return err
+	}
+
+	problems := make([]string, 0)
+	for name, v := range c.values {
+		k, err := lookupKey(name)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", c.path, err))
+		} else if _, _, ok := splitProfileKey(name); ok && k.validate != nil {
+			if err := k.validate(v); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", name, err))
+			}
+		}
+	}
+	sort.Strings(problems)
+
+	for _, k := range Keys {
+		v, source := Resolve(k.Name)
+		if v == "" || k.validate == nil {
+			continue
+		}
+
+		if err := k.validate(v); err != nil {
+			problems = append(problems, fmt.Sprintf("%s (from %s): %s", describe(k), source, err))
+		}
+	}
+
+	// tokens and usernames have fallbacks, see the credentials package
+	required := []string{BaseDir}
+	profiles := ProfileNames()
+	if p := Get(Provider); len(profiles) == 0 && (p == provider.Gitea || p == provider.Forgejo) {
+		required = append(required, GiteaUrl)
+	}
+
+	for _, profile := range profiles {
+		p := c.values[ProfileKey(profile, ProfileProvider)]
+		if p == "" {
+			p = Get(Provider)
+		}
+
+		if p == provider.Gitea || p == provider.Forgejo {
+			required = append(required, ProfileKey(profile, ProfileUrl))
+		}
+	}
+
+	for _, name := range required {
+		if Get(name) == "" {
+			k, _ := lookupKey(name)
+			if k.Env == "" {
+				problems = append(problems, fmt.Sprintf("%s is not set, run: sgit config set %s <value>", k.Name, k.Name))
+				continue
+			}
+			problems = append(problems, fmt.Sprintf("%s is not set, export %s or run: sgit config set %s <value>", describe(k), k.Env, k.Name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration:\n  " + strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func describe(k Key) string {
+	return fmt.Sprintf("%s (%s)", k.Name, k.Env)
+}
+
+// Read parses the config file at path, a toml subset of [section] tables and
+// key = value pairs whose values are strings or bare numbers.
+func Read(path string) (*Config, error) {
+	c := &Config{path, make(map[string]string, 0)}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile failed: %w", err)
+	}
+
+	section := ""
+	for n, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			end := strings.Index(line, "]")
+			if end < 0 || !isComment(line[end+1:]) {
+				return nil, fmt.Errorf("%s:%d: invalid table header %s", path, n+1, line)
+			}
+			section = strings.TrimSpace(line[1:end])
+			continue
+		}
+
+		name, raw, err := parseKey(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n+1, err)
+		}
+
+		v, err := parseValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n+1, err)
+		}
+
+		if section != "" {
+			name = section + "." + name
+		}
+		c.values[name] = v
+	}
+
+	return c, nil
+}
+
+// parseKey splits line into its key, unquoted if need be, and the raw value
+// after the =.
+func parseKey(line string) (string, string, error) {
+	if !strings.HasPrefix(line, `"`) {
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return "", "", errors.New("expected key = value")
+		}
+		return strings.TrimSpace(key), strings.TrimSpace(raw), nil
+	}
+
+	end := closingQuote(line)
+	if end < 0 {
+		return "", "", fmt.Errorf("unterminated key %s", line)
+	}
+
+	key, err := strconv.Unquote(line[:end+1])
+	if err != nil {
+		return "", "", fmt.Errorf("invalid key %s", line[:end+1])
+	}
+
+	raw, ok := strings.CutPrefix(strings.TrimSpace(line[end+1:]), "=")
+	if !ok {
+		return "", "", errors.New("expected key = value")
+	}
+	return key, strings.TrimSpace(raw), nil
+}
+
+// parseValue unquotes a string value or trims a bare one, dropping a
+// trailing comment either way.
+func parseValue(raw string) (string, error) {
+	if !strings.HasPrefix(raw, `"`) {
+		v, _, _ := strings.Cut(raw, "#")
+		return strings.TrimSpace(v), nil
+	}
+
+	end := closingQuote(raw)
+	if end < 0 {
+		return "", fmt.Errorf("unterminated string %s", raw)
+	}
+
+	if !isComment(raw[end+1:]) {
+		return "", fmt.Errorf("unexpected %s after string", strings.TrimSpace(raw[end+1:]))
+	}
+
+	v, err := strconv.Unquote(raw[:end+1])
+	if err != nil {
+		return "", fmt.Errorf("invalid string %s", raw[:end+1])
+	}
+	return v, nil
+}
+
+// closingQuote returns the index of the quote ending the string s starts
+// with, skipping escaped quotes, or -1 if it is unterminated.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func isComment(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || strings.HasPrefix(s, "#")
+}
+
+func (c Config) Path() string {
+	return c.path
+}
+
+// Values returns the settings present in the file, keyed by name.
+func (c Config) Values() map[string]string {
+	rv := make(map[string]string, len(c.values))
+	for k, v := range c.values {
+		rv[k] = v
+	}
+	return rv
+}
+
+// Set validates and stores value under name, an empty value removes it.
+func (c *Config) Set(name, value string) error {
+	k, err := lookupKey(name)
+	if err != nil {
+		return err
+	}
+
+	if value == "" {
+		delete(c.values, name)
+		return nil
+	}
+
+	if k.validate != nil {
+		if err := k.validate(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	c.values[name] = value
+	return nil
+}
+
+// Save rewrites the config file, keys are written in sorted order with
+// top-level keys first. Comments in the existing file are not preserved.
+func (c Config) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), configDirPermissions); err != nil {
+		return fmt.Errorf("os.MkdirAll failed: %w", err)
+	}
+
+	names := make([]string, 0)
+	for name := range c.values {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(a, b int) bool {
+		sa, _ := splitKey(names[a])
+		sb, _ := splitKey(names[b])
+		if sa != sb {
+			return sa < sb
+		}
+		return names[a] < names[b]
+	})
+
+	var sb strings.Builder
+	section := ""
+	for _, name := range names {
+		s, key := splitKey(name)
+		if s != section {
+			fmt.Fprintf(&sb, "\n[%s]\n", s)
+			section = s
+		}
+		if !bareKey.MatchString(key) {
+			key = strconv.Quote(key)
+		}
+		fmt.Fprintf(&sb, "%s = %s\n", key, strconv.Quote(c.values[name]))
+	}
+
+	if err := os.WriteFile(c.path, []byte(strings.TrimPrefix(sb.String(), "\n")), filePermissions); err != nil {
+		return fmt.Errorf("os.WriteFile failed: %w", err)
+	}
+	return nil
+}
+
+// bareKey matches the keys toml allows without quotes.
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// splitKey splits a dotted name into its table and key, the key being
+// everything after the last dot. Keys of the Languages and Repos tables may
+// contain dots themselves, so they are split after the table name instead.
+func splitKey(name string) (string, string) {
+	for _, table := range []string{Languages, Repos} {
+		if strings.HasPrefix(name, table+".") {
+			return table, strings.TrimPrefix(name, table+".")
+		}
+	}
+
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return "", name
+	}
+	return name[:i], name[i+1:]
+}
+
+// Display is the value to show for name, secrets are redacted.
+func Display(name, value string) string {
+	k, err := lookupKey(name)
+	if err == nil && k.Secret && value != "" {
+		return redacted
+	}
+	return value
+}
+
+func validateDir(v string) error {
+	info, err := os.Stat(v)
+	if err != nil {
+		return fmt.Errorf("%s does not exist", v)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", v)
+	}
+	return nil
+}
+
+func validateLayout(v string) error {
+	if v != DefaultLayout {
+		return fmt.Errorf("unsupported layout %q, only %s is supported", v, DefaultLayout)
+	}
+	return nil
+}
+
+func validateProvider(v string) error {
+	switch v {
+	case provider.Github, provider.Gitlab, provider.Gitea, provider.Forgejo:
+		return nil
+	}
+	return fmt.Errorf("unknown provider %q, expected github, gitlab, gitea or forgejo", v)
+}
+
+func validatePositiveInt(v string) error {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return fmt.Errorf("%q is not a positive number", v)
+	}
+	return nil
+}
+
+func validateAffiliations(v string) error {
+	for _, a := range strings.Split(v, ",") {
+		switch strings.TrimSpace(a) {
+		case github.AffiliationOwner, github.AffiliationCollaborator, github.AffiliationOrganizationMember:
+		default:
+			return fmt.Errorf("unknown affiliation %q", a)
+		}
+	}
+	return nil
+}
+
+func validateProfiles(v string) error {
+	defined := ProfileNames()
+	for _, p := range strings.Split(v, ",") {
+		p = strings.TrimSpace(p)
+		found := false
+		for _, d := range defined {
+			found = found || d == p
+		}
+
+		if !found {
+			return fmt.Errorf("unknown profile %q, profiles are defined as [profile.<name>] tables in %s", p, Path())
+		}
+	}
+	return nil
+}
+
+func validateDuration(v string) error {
+	if _, err := time.ParseDuration(v); err != nil {
+		return fmt.Errorf("%q is not a duration, e.g. 2m", v)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:    "empty",
+			content: "",
+			want:    map[string]string{},
+		},
+		{
+			name: "top-level and sections",
+			content: `base_dir = "/code"
+concurrency = 8
+
+[github]
+token = "abc"
+
+[profile.work]
+provider = "gitea"
+`,
+			want: map[string]string{
+				"base_dir":              "/code",
+				"concurrency":           "8",
+				"github.token":          "abc",
+				"profile.work.provider": "gitea",
+			},
+		},
+		{
+			name: "comments",
+			content: `# leading comment
+  # indented comment
+token = "abc" # the "work" one
+concurrency = 8 # bare value
+[github] # table comment
+url = "https://example.com/#anchor"
+`,
+			want: map[string]string{
+				"token":       "abc",
+				"concurrency": "8",
+				"github.url":  "https://example.com/#anchor",
+			},
+		},
+		{
+			name: "quoted keys",
+			content: `[repos]
+"me/x.github.io" = "vim"
+"org/a=b" = "go"
+"esc\"aped" = "c"
+
+[languages]
+"vim script" = "vim"
+`,
+			want: map[string]string{
+				"repos.me/x.github.io": "vim",
+				"repos.org/a=b":        "go",
+				`repos.esc"aped`:       "c",
+				"languages.vim script": "vim",
+			},
+		},
+		{
+			name:    "escapes in values",
+			content: `token = "a\"b\\c" # "quoted" comment`,
+			want:    map[string]string{"token": `a"b\c`},
+		},
+		{
+			name:    "later values win",
+			content: "token = \"a\"\ntoken = \"b\"\n",
+			want:    map[string]string{"token": "b"},
+		},
+		{
+			name:    "windows line endings",
+			content: "[github]\r\ntoken = \"abc\"\r\n",
+			want:    map[string]string{"github.token": "abc"},
+		},
+		{
+			name:    "missing equals",
+			content: "token \"abc\"",
+			wantErr: ":1: expected key = value",
+		},
+		{
+			name:    "missing key",
+			content: `= "abc"`,
+			wantErr: ":1: expected key = value",
+		},
+		{
+			name:    "unterminated string",
+			content: "\n" + `token = "abc`,
+			wantErr: ":2: unterminated string",
+		},
+		{
+			name:    "text after string",
+			content: `token = "abc" def`,
+			wantErr: ":1: unexpected def after string",
+		},
+		{
+			name:    "unterminated key",
+			content: `"me/foo = "go"`,
+			wantErr: ":1: expected key = value",
+		},
+		{
+			name:    "quoted key without value",
+			content: `"me/foo" "go"`,
+			wantErr: ":1: expected key = value",
+		},
+		{
+			name:    "unterminated table",
+			content: "[github\ntoken = \"abc\"",
+			wantErr: ":1: invalid table header",
+		},
+		{
+			name:    "text after table",
+			content: "[github] token = \"abc\"",
+			wantErr: ":1: invalid table header",
+		},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "config.toml")
+		if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := Read(path)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: Read error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: Read failed: %v", tt.name, err)
+		} else if got := c.Values(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Read = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadMissingFile(t *testing.T) {
+	c, err := Read(filepath.Join(t.TempDir(), "missing.toml"))
+	if err != nil {
+		t.Fatalf("Read of a missing file failed: %v", err)
+	}
+	if len(c.Values()) != 0 {
+		t.Errorf("missing file has values %v", c.Values())
+	}
+}
+
+func TestSaveRoundTrips(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sgit", "config.toml")
+	base := filepath.Join(dir, "code dir")
+	if err := os.Mkdir(base, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]string{
+		BaseDir:                        base,
+		Concurrency:                    "8",
+		"languages.vim script":         "vim",
+		`repos.org/"quoted" # repo`:    "c#",
+		ProfileKey("work", "url"):      "https://gitea.example.com",
+		ProfileKey("work", "ssh_host"): "gitea-work",
+	}
+	for name, v := range values {
+		if err := c.Set(name, v); err != nil {
+			t.Fatalf("Set(%s) failed: %v", name, err)
+		}
+	}
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	read, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read of the saved file failed: %v", err)
+	}
+	if got := read.Values(); !reflect.DeepEqual(got, values) {
+		t.Errorf("saved and read back %v, want %v", got, values)
+	}
+}
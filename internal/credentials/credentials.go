@@ -0,0 +1,69 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sgit/git"
+	"sgit/internal/config"
+)
+
+const (
+	SourceConfig = "config"
+	SourceEnv    = "env"
+	SourceGh     = "gh"
+	SourceGit    = "git credential"
+	SourceStore  = "sgit store"
+)
+
+// ErrNotFound is returned when no source has a token for the host.
+var ErrNotFound = errors.New("no token found")
+
+// Credential is a token for a host, Username is only set when the source
+// knows it.
+type Credential struct {
+	Host, Username, Token, Source string
+}
+
+// Resolve looks for a token for host in order: the tokenKey setting (its env
+// var or the config file), the gh CLI's hosts.yml, git's credential helpers
+// and finally sgit's own encrypted store. A username picks between several
+// accounts on the same host, where the source supports it.
+func Resolve(ctx context.Context, g *git.Git, host, username, tokenKey string) (*Credential, error) {
+	if v, source := config.Resolve(tokenKey); v != "" {
+		if source != SourceEnv {
+			source = SourceConfig
+		}
+		return &Credential{host, username, v, source}, nil
+	}
+
+	if c, err := fromGh(host, username); err != nil {
+		return nil, fmt.Errorf("credentials.fromGh failed: %w", err)
+	} else if c != nil {
+		return c, nil
+	}
+
+	if c, err := g.CredentialFill(ctx, host, username); err != nil {
+		return nil, fmt.Errorf("git.CredentialFill failed: %w", err)
+	} else if c != nil {
+		return &Credential{host, c.Username, c.Password, SourceGit}, nil
+	}
+
+	s, err := OpenStore()
+	if err != nil {
+		return nil, fmt.Errorf("credentials.OpenStore failed: %w", err)
+	}
+	if c, ok := s.Get(host, username); ok {
+		return c, nil
+	}
+
+	if username != "" {
+		host = username + "@" + host
+	}
+	return nil, fmt.Errorf(
+		"%w for %s: set %s, run gh auth login, configure a git credential helper or run sgit auth store",
+		ErrNotFound,
+		host,
+		tokenKey,
+	)
+}
@@ -0,0 +1,105 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ghHostsPath is where the gh CLI keeps its per-host settings.
+func ghHostsPath() string {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "hosts.yml")
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "gh", "hosts.yml")
+}
+
+// fromGh reads host's token from gh's hosts.yml, for username when it is set
+// and gh is logged in to several accounts on the host. Recent gh versions
+// keep tokens in the OS keyring instead, in which case there is nothing to
+// find.
+func fromGh(host, username string) (*Credential, error) {
+	path := ghHostsPath()
+	if path == "" {
+		return nil, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile failed: %w", err)
+	}
+
+	fields := parseYaml(string(b))
+	active := fields[host+"/user"]
+	if username == "" {
+		username = active
+	}
+
+	token := fields[host+"/users/"+username+"/oauth_token"]
+	if token == "" && username == active {
+		token = fields[host+"/oauth_token"]
+	}
+
+	if token == "" {
+		return nil, nil
+	}
+	return &Credential{host, username, token, SourceGh}, nil
+}
+
+// parseYaml reads the subset of yaml gh writes to hosts.yml, nested maps of
+// scalars, flattening it to slash separated paths such as
+// github.com/users/octocat/oauth_token.
+func parseYaml(content string) map[string]string {
+	rv := make(map[string]string, 0)
+
+	type level struct {
+		indent int
+		key    string
+	}
+	stack := make([]level, 0)
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		k, v, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		path := make([]string, 0)
+		for _, l := range stack {
+			path = append(path, l.key)
+		}
+		path = append(path, strings.TrimSpace(k))
+
+		v = strings.Trim(strings.TrimSpace(v), `"'`)
+		if v == "" {
+			stack = append(stack, level{indent, strings.TrimSpace(k)})
+			continue
+		}
+		rv[strings.Join(path, "/")] = v
+	}
+
+	return rv
+}
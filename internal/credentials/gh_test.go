@@ -0,0 +1,132 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// multiUserHosts is hosts.yml as gh 2.40 and later write it, logged in to two
+// accounts on github.com.
+const multiUserHosts = `github.com:
+    users:
+        octocat:
+            oauth_token: gho_octocat
+        octo-work:
+            oauth_token: "gho_work"
+    git_protocol: ssh
+    user: octocat
+    oauth_token: gho_octocat
+ghe.example.com:
+    users:
+        me:
+            # the token is in the keyring
+    user: me
+`
+
+// legacyHosts is hosts.yml from before gh supported several accounts.
+const legacyHosts = `github.com:
+    oauth_token: 'gho_legacy'
+    user: octocat
+    git_protocol: https
+`
+
+func TestParseYaml(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+	}{
+		{
+			name:    "multiple users",
+			content: multiUserHosts,
+			want: map[string]string{
+				"github.com/users/octocat/oauth_token":   "gho_octocat",
+				"github.com/users/octo-work/oauth_token": "gho_work",
+				"github.com/git_protocol":                "ssh",
+				"github.com/user":                        "octocat",
+				"github.com/oauth_token":                 "gho_octocat",
+				"ghe.example.com/user":                   "me",
+			},
+		},
+		{
+			name:    "legacy",
+			content: legacyHosts,
+			want: map[string]string{
+				"github.com/oauth_token":  "gho_legacy",
+				"github.com/user":         "octocat",
+				"github.com/git_protocol": "https",
+			},
+		},
+		{
+			name:    "tabs and blank lines",
+			content: "github.com:\n\n\tuser: octocat\n\toauth_token: abc\r\n",
+			want: map[string]string{
+				"github.com/user":        "octocat",
+				"github.com/oauth_token": "abc",
+			},
+		},
+		{
+			name:    "empty",
+			content: "",
+			want:    map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := parseYaml(tt.content); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseYaml = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFromGh(t *testing.T) {
+	tests := []struct {
+		name, content, host, username string
+		// wantUser and wantToken are empty when nothing should be found
+		wantUser, wantToken string
+	}{
+		{"active user", multiUserHosts, "github.com", "", "octocat", "gho_octocat"},
+		{"named user", multiUserHosts, "github.com", "octo-work", "octo-work", "gho_work"},
+		{"unknown user", multiUserHosts, "github.com", "someone", "", ""},
+		{"token in keyring", multiUserHosts, "ghe.example.com", "", "", ""},
+		{"unknown host", multiUserHosts, "gitlab.com", "", "", ""},
+		{"legacy", legacyHosts, "github.com", "", "octocat", "gho_legacy"},
+		{"legacy named active user", legacyHosts, "github.com", "octocat", "octocat", "gho_legacy"},
+		{"legacy other user", legacyHosts, "github.com", "octo-work", "", ""},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		t.Setenv("GH_CONFIG_DIR", dir)
+		if err := os.WriteFile(filepath.Join(dir, "hosts.yml"), []byte(tt.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := fromGh(tt.host, tt.username)
+		if err != nil {
+			t.Errorf("%s: fromGh failed: %v", tt.name, err)
+			continue
+		}
+
+		if tt.wantToken == "" {
+			if c != nil {
+				t.Errorf("%s: fromGh = %+v, want nothing", tt.name, c)
+			}
+			continue
+		}
+
+		want := &Credential{tt.host, tt.wantUser, tt.wantToken, SourceGh}
+		if !reflect.DeepEqual(c, want) {
+			t.Errorf("%s: fromGh = %+v, want %+v", tt.name, c, want)
+		}
+	}
+}
+
+func TestFromGhWithoutHostsFile(t *testing.T) {
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	if c, err := fromGh("github.com", ""); err != nil || c != nil {
+		t.Errorf("fromGh = %+v, %v, want nothing", c, err)
+	}
+}
@@ -0,0 +1,202 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sgit/internal/config"
+)
+
+const (
+	keyEnv          = "SGIT_CREDENTIALS_KEY"
+	keySize         = 32
+	dirPermissions  = 0o700
+	filePermissions = 0o600
+)
+
+// Store keeps tokens in an AES-GCM encrypted file next to the config file.
+// The key comes from SGIT_CREDENTIALS_KEY or, failing that, a generated key
+// file kept under $XDG_DATA_HOME rather than beside the store, so copying or
+// committing the config directory doesn't leak usable tokens.
+type Store struct {
+	path, keyPath string
+	entries       map[string]storeEntry
+}
+
+type storeEntry struct {
+	Username string `json:"username,omitempty"`
+	Token    string `json:"token"`
+}
+
+// OpenStore decrypts the store, a missing store is empty.
+func OpenStore() (*Store, error) {
+	s := &Store{
+		path:    filepath.Join(filepath.Dir(config.Path()), "credentials"),
+		keyPath: keyPath(),
+		entries: make(map[string]storeEntry, 0),
+	}
+
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile failed: %w", err)
+	}
+
+	aead, err := s.cipher(false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) < aead.NonceSize() {
+		return nil, fmt.Errorf("%s is truncated", s.path)
+	}
+
+	plain, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s, was the key changed?", s.path)
+	}
+
+	if err := json.Unmarshal(plain, &s.entries); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal failed: %w", err)
+	}
+	return s, nil
+}
+
+func (s Store) Path() string {
+	return s.path
+}
+
+// Get returns the token stored for username on host, falling back to one
+// stored without a username.
+func (s Store) Get(host, username string) (*Credential, bool) {
+	e, ok := s.entries[storeKey(host, username)]
+	if !ok {
+		e, ok = s.entries[host]
+	}
+
+	if !ok || (username != "" && e.Username != "" && e.Username != username) {
+		return nil, false
+	}
+	return &Credential{host, e.Username, e.Token, SourceStore}, true
+}
+
+func (s *Store) Put(host, username, token string) {
+	s.entries[storeKey(host, username)] = storeEntry{username, token}
+}
+
+func (s *Store) Delete(host, username string) bool {
+	_, ok := s.entries[storeKey(host, username)]
+	delete(s.entries, storeKey(host, username))
+	return ok
+}
+
+// storeKey is user@host, or just host for tokens stored without a username.
+func storeKey(host, username string) string {
+	if username == "" {
+		return host
+	}
+	return username + "@" + host
+}
+
+// Save encrypts the store with a fresh nonce and writes it out.
+func (s Store) Save() error {
+	plain, err := json.Marshal(s.entries)
+	if err != nil {
+		return fmt.Errorf("json.Marshal failed: %w", err)
+	}
+
+	aead, err := s.cipher(true)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("rand.Read failed: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), dirPermissions); err != nil {
+		return fmt.Errorf("os.MkdirAll failed: %w", err)
+	}
+
+	if err := os.WriteFile(s.path, aead.Seal(nonce, nonce, plain, nil), filePermissions); err != nil {
+		return fmt.Errorf("os.WriteFile failed: %w", err)
+	}
+	return nil
+}
+
+// cipher builds the AES-GCM cipher, generating the key file when create is
+// set and there is no key yet.
+func (s Store) cipher(create bool) (cipher.AEAD, error) {
+	key, err := s.key(create)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("aes.NewCipher failed: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s Store) key(create bool) ([]byte, error) {
+	if v := os.Getenv(keyEnv); v != "" {
+		sum := sha256.Sum256([]byte(v))
+		return sum[:], nil
+	}
+
+	key, err := os.ReadFile(s.keyPath)
+	if err == nil {
+		if len(key) != keySize {
+			return nil, fmt.Errorf("%s is not a %d byte key", s.keyPath, keySize)
+		}
+		return key, nil
+	}
+
+	if !errors.Is(err, os.ErrNotExist) || !create {
+		return nil, fmt.Errorf("failed to read key %s: %w", s.keyPath, err)
+	}
+
+	key = make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("rand.Read failed: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.keyPath), dirPermissions); err != nil {
+		return nil, fmt.Errorf("os.MkdirAll failed: %w", err)
+	}
+
+	// O_EXCL so two concurrent first saves can't overwrite each other's key
+	f, err := os.OpenFile(s.keyPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, filePermissions)
+	if err != nil {
+		return nil, fmt.Errorf("os.OpenFile failed: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(key); err != nil {
+		return nil, fmt.Errorf("failed to write key %s: %w", s.keyPath, err)
+	}
+	return key, nil
+}
+
+func keyPath() string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join(".local", "share", "sgit", "credentials.key")
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "sgit", "credentials.key")
+}
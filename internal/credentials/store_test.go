@@ -0,0 +1,139 @@
+package credentials
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// storeEnv points the store and its key at a temporary directory, returning
+// the key's path.
+func storeEnv(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("SGIT_CONFIG", filepath.Join(dir, "config", "config.toml"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv(keyEnv, "")
+	return filepath.Join(dir, "data", "sgit", "credentials.key")
+}
+
+func TestStoreRoundTrips(t *testing.T) {
+	keyPath := storeEnv(t)
+
+	s, err := OpenStore()
+	if err != nil {
+		t.Fatalf("OpenStore of a missing store failed: %v", err)
+	}
+	if _, ok := s.Get("github.com", ""); ok {
+		t.Error("a new store has a token")
+	}
+
+	s.Put("github.com", "", "ghp_default")
+	s.Put("github.com", "work", "ghp_work")
+	s.Put("gitea.example.com", "me", "gitea_token")
+	s.Put("gitlab.com", "gone", "glpat_gone")
+	if !s.Delete("gitlab.com", "gone") {
+		t.Error("Delete of a stored token reported nothing deleted")
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		t.Fatalf("Save didn't create the key: %v", err)
+	}
+	if info.Mode().Perm() != filePermissions || info.Size() != keySize {
+		t.Errorf("key is %d bytes with mode %v, want %d bytes with %v", info.Size(), info.Mode().Perm(), keySize, os.FileMode(filePermissions))
+	}
+
+	b, err := os.ReadFile(s.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(b, []byte("ghp_")) {
+		t.Error("the store holds plaintext tokens")
+	}
+
+	s, err = OpenStore()
+	if err != nil {
+		t.Fatalf("OpenStore of the saved store failed: %v", err)
+	}
+
+	tests := []struct {
+		host, username string
+		want           *Credential
+	}{
+		{"github.com", "", &Credential{"github.com", "", "ghp_default", SourceStore}},
+		{"github.com", "work", &Credential{"github.com", "work", "ghp_work", SourceStore}},
+		// falls back to the token stored without a username
+		{"github.com", "other", &Credential{"github.com", "", "ghp_default", SourceStore}},
+		{"gitea.example.com", "me", &Credential{"gitea.example.com", "me", "gitea_token", SourceStore}},
+		{"gitea.example.com", "other", nil},
+		{"gitlab.com", "gone", nil},
+	}
+	for _, tt := range tests {
+		c, ok := s.Get(tt.host, tt.username)
+		if ok != (tt.want != nil) || !reflect.DeepEqual(c, tt.want) {
+			t.Errorf("Get(%s, %q) = %+v, %t, want %+v", tt.host, tt.username, c, ok, tt.want)
+		}
+	}
+}
+
+func TestStoreWrongKey(t *testing.T) {
+	keyPath := storeEnv(t)
+
+	s, err := OpenStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Put("github.com", "", "ghp_secret")
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if err := os.WriteFile(keyPath, bytes.Repeat([]byte{1}, keySize), filePermissions); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenStore(); err == nil || !strings.Contains(err.Error(), "failed to decrypt") {
+		t.Errorf("OpenStore with a replaced key file = %v, want a decrypt error", err)
+	}
+
+	if err := os.Remove(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenStore(); err == nil || !strings.Contains(err.Error(), "failed to read key") {
+		t.Errorf("OpenStore without its key = %v, want a missing key error", err)
+	}
+}
+
+func TestStoreKeyFromEnv(t *testing.T) {
+	keyPath := storeEnv(t)
+	t.Setenv(keyEnv, "passphrase")
+
+	s, err := OpenStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Put("github.com", "", "ghp_secret")
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := os.Stat(keyPath); !os.IsNotExist(err) {
+		t.Errorf("a key file was written although %s is set: %v", keyEnv, err)
+	}
+
+	if s, err := OpenStore(); err != nil {
+		t.Errorf("OpenStore with the same passphrase failed: %v", err)
+	} else if c, ok := s.Get("github.com", ""); !ok || c.Token != "ghp_secret" {
+		t.Errorf("Get = %+v, want ghp_secret", c)
+	}
+
+	t.Setenv(keyEnv, "another passphrase")
+	if _, err := OpenStore(); err == nil || !strings.Contains(err.Error(), "failed to decrypt") {
+		t.Errorf("OpenStore with another passphrase = %v, want a decrypt error", err)
+	}
+}
@@ -8,13 +8,13 @@ import (
 
 type Filter struct {
 	langs  *set.Set[string]
-	states *set.Set[State]
+	states [][]State
 	names  []string
 	forks  *bool
 }
 
 func NewFilter(langs, states, names string, forks *bool) (*Filter, error) {
-	ss, err := statesSet(states)
+	ss, err := parseStates(states)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create state set: %w", err)
 	}
@@ -52,7 +52,7 @@ func (f Filter) Include(rsp RepoStatePair) bool {
 		return false
 	}
 
-	if f.states.Size() > 0 && !f.states.Contains(rsp.State) {
+	if len(f.states) > 0 && !matchesAny(rsp.State, f.states) {
 		return false
 	}
 
@@ -69,49 +69,74 @@ func (f Filter) Include(rsp RepoStatePair) bool {
 	return true
 }
 
-func statesSet(commaSeparated string) (*set.Set[State], error) {
-	normalize := func(s State) string {
-		return strings.ToLower(s.String())
-	}
+// matchesAny reports whether s satisfies any of the clauses, where a clause
+// is satisfied when s has at least one flag from each of its masks.
+func matchesAny(s State, clauses [][]State) bool {
+	for _, clause := range clauses {
+		matches := true
+		for _, mask := range clause {
+			if s&mask == 0 {
+				matches = false
+				break
+			}
+		}
 
-	states := map[string]State{
-		normalize(UpToDate):                         UpToDate,
-		normalize(UncommittedChanges):               UncommittedChanges,
-		normalize(NotGitRepo):                       NotGitRepo,
-		normalize(NoRemoteRepo):                     NoRemoteRepo,
-		normalize(IncorrectLanguageParentDirectory): IncorrectLanguageParentDirectory,
-		normalize(NotCloned):                        NotCloned,
+		if matches {
+			return true
+		}
 	}
 
-	rv := set.New[State]()
-	for _, s := range strings.Split(commaSeparated, ",") {
-		if len(s) == 0 {
+	return false
+}
+
+// parseStates parses the -s flag: a comma-separated list of alternatives,
+// each of which may require several states at once by joining them with "+"
+// (e.g. "ahead+stash,diverged"). Names are matched case-insensitively by
+// prefix, a prefix matching several states matches any of them.
+func parseStates(flag string) ([][]State, error) {
+	rv := make([][]State, 0)
+	for _, alternative := range strings.Split(flag, ",") {
+		if len(alternative) == 0 {
 			continue
 		}
 
-		ls := strings.ToLower(s)
-		found := false
-		for normalizedName, state := range states {
-			if strings.HasPrefix(normalizedName, ls) {
-				found = true
-				rv.Add(state)
+		clause := make([]State, 0)
+		for _, s := range strings.Split(alternative, "+") {
+			mask, err := parseState(s)
+			if err != nil {
+				return nil, err
 			}
+			clause = append(clause, mask)
 		}
 
-		if !found {
-			vs := make([]string, len(states))
-			for _, s := range states {
-				vs = append(vs, s.String())
-			}
+		rv = append(rv, clause)
+	}
 
-			return nil, fmt.Errorf(
-				"\ninvalid -states flag: \"%s\" \nvalid flags: %s",
-				s,
-				strings.Join(vs, " "),
-			)
+	return rv, nil
+}
+
+func parseState(s string) (State, error) {
+	ls := strings.ToLower(s)
+
+	var mask State
+	for _, state := range AllStates {
+		if strings.HasPrefix(strings.ToLower(state.String()), ls) {
+			mask |= state
+		}
+	}
+
+	if len(ls) == 0 || mask == 0 {
+		vs := make([]string, 0)
+		for _, state := range AllStates {
+			vs = append(vs, state.String())
 		}
 
+		return 0, fmt.Errorf(
+			"\ninvalid -states flag: \"%s\" \nvalid flags: %s",
+			s,
+			strings.Join(vs, " "),
+		)
 	}
 
-	return rv, nil
+	return mask, nil
 }
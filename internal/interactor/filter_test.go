@@ -0,0 +1,105 @@
+package interactor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseStates(t *testing.T) {
+	tests := []struct {
+		flag    string
+		want    [][]State
+		wantErr string
+	}{
+		{flag: "", want: [][]State{}},
+		{flag: ",", want: [][]State{}},
+		{flag: "Ahead", want: [][]State{{Ahead}}},
+		{flag: "ahead", want: [][]State{{Ahead}}},
+		{flag: "uptodate,behind", want: [][]State{{UpToDate}, {Behind}}},
+		{flag: "u", want: [][]State{{UpToDate | UncommittedChanges}}},
+		{flag: "no", want: [][]State{{NotGitRepo | NoRemoteRepo | NotCloned}}},
+		{flag: "not", want: [][]State{{NotGitRepo | NotCloned}}},
+		{flag: "ahead+stash", want: [][]State{{Ahead, StashPresent}}},
+		{flag: "a+s,d", want: [][]State{{Ahead, StashPresent}, {Diverged | DetachedHead}}},
+		{flag: "bogus", wantErr: `"bogus"`},
+		{flag: "ahead,bogus", wantErr: `"bogus"`},
+		{flag: "ahead+", wantErr: `""`},
+		{flag: "aheadx", wantErr: `"aheadx"`},
+	}
+
+	for _, tt := range tests {
+		got, err := parseStates(tt.flag)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "UpToDate") {
+				t.Errorf("parseStates(%q) error = %v, want one naming %s and the valid states", tt.flag, err, tt.wantErr)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("parseStates(%q) failed: %v", tt.flag, err)
+		} else if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseStates(%q) = %v, want %v", tt.flag, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesAny(t *testing.T) {
+	tests := []struct {
+		flag  string
+		state State
+		want  bool
+	}{
+		{"u", UpToDate, true},
+		{"u", UncommittedChanges | Ahead, true},
+		{"u", Ahead, false},
+		{"ahead+stash", Ahead, false},
+		{"ahead+stash", Ahead | StashPresent, true},
+		{"ahead+stash", Ahead | StashPresent | UncommittedChanges, true},
+		{"ahead+stash,diverged", Diverged, true},
+		{"ahead+stash,diverged", StashPresent, false},
+		{"a+s,d", DetachedHead, true},
+		{"no+u", NoRemoteRepo | UncommittedChanges, true},
+		{"no+u", NotCloned, false},
+	}
+
+	for _, tt := range tests {
+		clauses, err := parseStates(tt.flag)
+		if err != nil {
+			t.Fatalf("parseStates(%q) failed: %v", tt.flag, err)
+		}
+		if got := matchesAny(tt.state, clauses); got != tt.want {
+			t.Errorf("%q matching %s = %t, want %t", tt.flag, tt.state, got, tt.want)
+		}
+	}
+}
+
+func TestFilterInclude(t *testing.T) {
+	yes := true
+	f, err := NewFilter("go,rust", "a+s,d", "cli", &yes)
+	if err != nil {
+		t.Fatalf("NewFilter failed: %v", err)
+	}
+
+	match := RepoStatePair{Repo: Repo{Name: "my-cli", Language: "go", Fork: true}, State: Ahead | StashPresent}
+	tests := []struct {
+		name   string
+		change func(*RepoStatePair)
+		want   bool
+	}{
+		{"matches", func(*RepoStatePair) {}, true},
+		{"other language", func(r *RepoStatePair) { r.Language = "c" }, false},
+		{"not a fork", func(r *RepoStatePair) { r.Fork = false }, false},
+		{"other state", func(r *RepoStatePair) { r.State = Ahead }, false},
+		{"other name", func(r *RepoStatePair) { r.Name = "server" }, false},
+	}
+
+	for _, tt := range tests {
+		rsp := match
+		tt.change(&rsp)
+		if got := f.Include(rsp); got != tt.want {
+			t.Errorf("%s: Include = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
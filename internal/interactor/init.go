@@ -0,0 +1,84 @@
+package interactor
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+)
+
+// Init turns dir into a git repo managed by sgit: it is initialised, moved
+// to <CODE_HOME_DIR>/<owner>/<lang>/<name> and, if createRemote is set,
+// pushed to a newly created remote repo as origin.
+func (i Interactor) Init(ctx context.Context, dir string, createRemote, private bool) (*Repo, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.filesystem.CreateDirectory(dir); err != nil {
+		return nil, fmt.Errorf("fs.CreateDirectory failed: %w", err)
+	}
+
+	if err := i.git.Init(ctx, dir); err != nil {
+		return nil, fmt.Errorf("git.Init failed: %w", err)
+	}
+
+	repo := Repo{
+		Name:    filepath.Base(dir),
+		Owner:   i.primary().Username,
+		Host:    i.primary().Host,
+		Profile: i.primary().Name,
+		GitRepo: true,
+	}
+
+	repo.Language = i.detectLanguage(repo, dir)
+
+	if target := repo.Path(); target != dir {
+		exists, err := i.filesystem.Exists(target)
+		if err != nil {
+			return nil, fmt.Errorf("fs.Exists failed: %w", err)
+		} else if exists {
+			return nil, fmt.Errorf("%s already exists", target)
+		}
+
+		if err := i.filesystem.CreateDirectory(filepath.Dir(target)); err != nil {
+			return nil, fmt.Errorf("fs.CreateDirectory failed: %w", err)
+		}
+
+		if err := i.filesystem.MoveDir(dir, target); err != nil {
+			return nil, fmt.Errorf("fs.MoveDir failed: %w", err)
+		}
+	}
+
+	if !createRemote {
+		return &repo, nil
+	}
+
+	a := i.primary()
+	remote, err := a.provider.CreateRepo(ctx, repo.Name, private)
+	if err != nil {
+		return &repo, fmt.Errorf("provider.CreateRepo failed: %w", err)
+	}
+	repo.URL = a.url(remote.SshUrl, remote.Owner, remote.Name)
+
+	if err := i.git.AddRemote(ctx, repo.Path(), "origin", repo.URL); err != nil {
+		return &repo, fmt.Errorf("git.AddRemote failed: %w", err)
+	}
+
+	commits, err := i.git.GetCommits(ctx, repo.Path(), 1)
+	if err != nil {
+		return &repo, fmt.Errorf("git.GetCommits failed: %w", err)
+	}
+
+	if len(commits) == 0 {
+		if err := i.git.CommitAll(ctx, repo.Path(), "initial commit"); err != nil {
+			return &repo, fmt.Errorf("git.CommitAll failed: %w", err)
+		}
+	}
+
+	if err := i.git.PushUpstream(ctx, repo.Path(), "origin"); err != nil {
+		return &repo, fmt.Errorf("git.PushUpstream failed: %w", err)
+	}
+
+	return &repo, nil
+}
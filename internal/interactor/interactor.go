@@ -2,56 +2,118 @@ package interactor
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
 	"sgit/filesystem"
 	"sgit/git"
 	"sgit/github"
+	"sgit/internal/config"
 	"sgit/internal/logging"
+	"sgit/provider"
+	"strconv"
 	"strings"
 	"sync"
 )
 
+// ErrIncompleteListing is returned along with the repo states when some
+// remote repos couldn't be listed, the states cover the repos that could.
+var ErrIncompleteListing = errors.New("some remote repos couldn't be listed")
+
 type Interactor struct {
-	logger            *logging.Logger
-	github            *github.Github
-	filesystem        *filesystem.Filesystem
-	git               *git.Git
-	username, baseDir string
+	logger      *logging.Logger
+	accounts    []account
+	languages   languageMap
+	filesystem  *filesystem.Filesystem
+	git         *git.Git
+	baseDir     string
+	concurrency int
 }
 
-func New() *Interactor {
-	baseDir := os.Getenv("CODE_HOME_DIR")
-	username := os.Getenv("GITHUB_USERNAME")
+// New authenticates every active profile, see ActiveProfiles.
+func New(ctx context.Context) (*Interactor, error) {
+	baseDir := config.Get(config.BaseDir)
+	logger := logging.New()
+
+	// validated with the rest of the config, a bad value can't get here
+	concurrency, _ := strconv.Atoi(config.Get(config.Concurrency))
+
+	accounts := make([]account, 0)
+	for _, p := range ActiveProfiles() {
+		a, err := newAccount(ctx, logger, p)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", p.Name, err)
+		}
+		accounts = append(accounts, *a)
+	}
+
 	return &Interactor{
-		logging.New(),
-		github.New(os.Getenv("GITHUB_TOKEN"), username),
+		logger,
+		accounts,
+		newLanguageMap(),
 		filesystem.New(baseDir),
 		git.New(),
-		username,
 		baseDir,
+		concurrency,
+	}, nil
+}
+
+// Concurrency returns how many repos may be worked on at once, see
+// config.Concurrency.
+func (i Interactor) Concurrency() int {
+	if i.concurrency < 1 {
+		return 1
 	}
+	return i.concurrency
+}
+
+// primary is the account used for repos that don't exist yet.
+func (i Interactor) primary() account {
+	return i.accounts[0]
+}
+
+// accountFor returns the account r was listed by, falling back to one on the
+// same host. Repos on a host no active profile owns are an error rather than
+// going to the primary account, which would act on a different repo.
+func (i Interactor) accountFor(r Repo) (account, error) {
+	for _, a := range i.accounts {
+		if a.Name == r.Profile {
+			return a, nil
+		}
+	}
+
+	for _, a := range i.accounts {
+		if a.owns(r.Host) && strings.EqualFold(a.Username, r.Owner) {
+			return a, nil
+		}
+	}
+
+	for _, a := range i.accounts {
+		if a.owns(r.Host) {
+			return a, nil
+		}
+	}
+
+	return account{}, fmt.Errorf("no active profile for host %s, add one as a [profile.<name>] table in %s", r.Host, config.Path())
 }
 
 // GetRepos returns a map of programing langauges to a list of RepositoryState Pair
 func (i Interactor) GetRepoStates(ctx context.Context, filter Filter) (map[string][]RepoStatePair, error) {
-	remoteRepos, err := i.getRemoteRepos(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("i.getRemoteRepos failed: %w", err)
-	}
+	remoteRepos, listErr := i.getRemoteRepos(ctx)
 
-	localRepoMap, err := i.getLocalRepoMap()
+	localRepoMap, err := i.getLocalRepoMap(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("local.GetRepos failed: %w", err)
 	}
 
+	matches := matchLocalToRemote(localRepoMap, remoteRepos)
+
 	repoStateMap := make(map[string]RepoStatePair, 0)
 	for _, remote := range remoteRepos {
 		rsp := RepoStatePair{
 			Repo: remote,
 		}
-		local, ok := localRepoMap[remote.FullName()]
+		local, ok := matches[remote.FullName()]
 		if !ok {
 			rsp.State = NotCloned
 			repoStateMap[remote.FullName()] = rsp
@@ -59,33 +121,39 @@ func (i Interactor) GetRepoStates(ctx context.Context, filter Filter) (map[strin
 		}
 
 		local.Fork = remote.Fork
+		local.Profile = remote.Profile
+		local.PushedAt = remote.PushedAt
+		local.Size = remote.Size
 		rsp = RepoStatePair{
 			Repo: local,
 		}
 
-		if remote.Language != local.Language {
-			if err := i.filesystem.MoveDir(local.Path(), remote.Path()); err != nil {
-				i.logger.Error(err, "filesystem.MoveDir failed", "repo_name", local.FullName())
-			} else {
-				local.Language = remote.Language
-				rsp.Repo.Language = remote.Language
-			}
+		if pin := readRepoPin(local.Path()); pin != "" {
+			remote.Language = dirName(pin)
+		} else if remote.Language == "" && local.Language == unknownLanguage {
+			remote.Language = i.detectLanguage(remote, local.Path())
 		}
 
-		if local.Language != remote.Language {
-			rsp.State = IncorrectLanguageParentDirectory
-		} else if local.UncommitedChanges {
-			// TODO: update this to handle the UncommitedChanges & UpToDate
-			rsp.State = UncommittedChanges
-		} else {
-			rsp.State = UpToDate
+		// listing never moves repos, see PlanMoves
+		rsp.State = localState(local)
+		if remote.Language != "" && local.Language != remote.Language {
+			rsp.State |= IncorrectLanguageParentDirectory
+			rsp.Repo.ExpectedLanguage = remote.Language
+		}
+		if rsp.State&outOfDate == 0 {
+			rsp.State |= UpToDate
 		}
 
 		repoStateMap[remote.FullName()] = rsp
 	}
 
+	matchedLocals := make(map[string]struct{}, 0)
+	for _, local := range matches {
+		matchedLocals[local.Path()] = struct{}{}
+	}
+
 	for _, local := range localRepoMap {
-		if _, ok := remoteRepos[local.FullName()]; ok {
+		if _, ok := matchedLocals[local.Path()]; ok {
 			continue
 		}
 
@@ -93,14 +161,14 @@ func (i Interactor) GetRepoStates(ctx context.Context, filter Filter) (map[strin
 			Repo: local,
 		}
 
-		switch {
-		case !local.GitRepo:
+		if local.GitRepo {
+			rsp.State = NoRemoteRepo | localState(local)
+			if lang := i.expectedLocalLanguage(local); lang != local.Language {
+				rsp.State |= IncorrectLanguageParentDirectory
+				rsp.Repo.ExpectedLanguage = lang
+			}
+		} else {
 			rsp.State = NotGitRepo
-		case local.UncommitedChanges:
-			// TODO: update this to handle the UncommitedChanges & UpToDate
-			rsp.State = UncommittedChanges
-		default:
-			rsp.State = NoRemoteRepo
 		}
 
 		repoStateMap[local.FullName()] = rsp
@@ -135,23 +203,89 @@ func (i Interactor) GetRepoStates(ctx context.Context, filter Filter) (map[strin
 		}
 	}
 
+	if listErr != nil {
+		return rv, fmt.Errorf("%w: %w", ErrIncompleteListing, listErr)
+	}
 	return rv, nil
 }
 
-func (i Interactor) getLocalRepoMap() (map[string]Repo, error) {
-	dirs, err := i.filesystem.ListDirectories()
+// matchLocalToRemote pairs local repos with the remote repo their primary
+// remote url points at, falling back to the <owner>/<name> directory they sit
+// in for repos whose url doesn't match (e.g. no remote, or an ssh host alias).
+// The result is keyed by the remote repo's FullName.
+func matchLocalToRemote(locals, remotes map[string]Repo) map[string]Repo {
+	remotesByUrl := make(map[string]Repo, 0)
+	for _, remote := range remotes {
+		if key := remote.urlKey(); key != "" {
+			remotesByUrl[key] = remote
+		}
+	}
+
+	rv := make(map[string]Repo, 0)
+	matchedByUrl := make(map[string]struct{}, 0)
+	for _, local := range locals {
+		if remote, ok := remotesByUrl[local.urlKey()]; ok {
+			rv[remote.FullName()] = local
+			matchedByUrl[local.FullName()] = struct{}{}
+		}
+	}
+
+	for _, local := range locals {
+		if _, ok := matchedByUrl[local.FullName()]; ok {
+			continue
+		}
+
+		if remote, ok := remotes[local.FullName()]; ok {
+			if _, taken := rv[remote.FullName()]; !taken {
+				rv[remote.FullName()] = local
+			}
+		}
+	}
+
+	return rv
+}
+
+func (i Interactor) getLocalRepoMap(ctx context.Context) (map[string]Repo, error) {
+	hosts, err := i.filesystem.ListHostDirectories()
 	if err != nil {
-		return nil, fmt.Errorf("filesystem.ListDirectories failed: %w", err)
+		return nil, fmt.Errorf("filesystem.ListHostDirectories failed: %w", err)
+	}
+
+	type hostDir struct{ host, root, dir string }
+	targets := make([]hostDir, 0)
+	for _, host := range append(hosts, "") {
+		// the github.com layout sits beside the host directories
+		skip := []string{}
+		if host == "" {
+			skip = hosts
+		}
+
+		dirs, err := i.filesystem.ListDirectories(host, skip...)
+		if err != nil {
+			return nil, fmt.Errorf("filesystem.ListDirectories failed: %w", err)
+		}
+
+		for _, dir := range dirs {
+			if host != "" {
+				targets = append(targets, hostDir{host, filepath.Join(i.baseDir, host), dir})
+			} else {
+				targets = append(targets, hostDir{github.DefaultHost, i.baseDir, dir})
+			}
+		}
 	}
 
+	sem := make(chan struct{}, i.Concurrency())
 	var wg sync.WaitGroup
-	results := make(chan Repo, len(dirs))
-	for _, dir := range dirs {
+	results := make(chan Repo, len(targets))
+	for _, t := range targets {
 		wg.Add(1)
-		go func(dir string, results chan<- Repo) {
+		go func(t hostDir, results chan<- Repo) {
 			defer wg.Done()
-			results <- i.normalize(dir)
-		}(dir, results)
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results <- i.normalize(ctx, t.host, t.root, t.dir)
+		}(t, results)
 	}
 
 	go func() {
@@ -167,10 +301,17 @@ func (i Interactor) getLocalRepoMap() (map[string]Repo, error) {
 	return rv, nil
 }
 
-func (i Interactor) normalize(dir string) Repo {
+// normalize builds the repo at dir, <owner>/<lang>/<name> beneath root. The
+// owner may span several directories for repos of nested groups.
+func (i Interactor) normalize(ctx context.Context, host, root, dir string) Repo {
 	dir = strings.TrimSuffix(dir, "/")
 
-	p := strings.Split(dir, "/")
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		rel = dir
+	}
+
+	p := strings.Split(filepath.ToSlash(rel), "/")
 	name := p[len(p)-1]
 	lang := ""
 	owner := ""
@@ -179,13 +320,14 @@ func (i Interactor) normalize(dir string) Repo {
 	}
 
 	if len(p) > 2 {
-		owner = p[len(p)-3]
+		owner = strings.Join(p[:len(p)-2], "/")
 	}
 
 	repo := Repo{
 		Name:     name,
 		Language: lang,
 		Owner:    owner,
+		Host:     host,
 	}
 
 	fullPath := filepath.Join(dir, ".git/")
@@ -199,50 +341,83 @@ func (i Interactor) normalize(dir string) Repo {
 		return repo
 	}
 
-	sshUrl, err := i.git.GetSshUrl(dir)
+	remotes, err := i.git.GetRemotes(ctx, dir)
 	if err != nil {
-		i.logger.Error(err, "git.GetSshUrl failed", "name", name, "lang", lang)
+		i.logger.Error(err, "git.GetRemotes failed", "name", name, "lang", lang)
+	}
+	repo.Remotes = remotes
+	if r := git.PrimaryRemote(remotes); r != nil {
+		repo.URL = r.URL
+	}
+	if r := git.UpstreamRemote(remotes); r != nil {
+		repo.UpstreamURL = r.URL
 	}
-	repo.URL = sshUrl
 
-	uncommittedChanges, err := i.git.HasUncommittedChanges(dir)
+	status, err := i.git.Status(ctx, dir)
 	if err != nil {
-		i.logger.Error(err, "git.HasUncommittedChanges failed", "name", name, "lang", lang)
+		i.logger.Error(err, "git.Status failed", "name", name, "lang", lang)
+	} else {
+		repo.Status = *status
+		repo.UncommitedChanges = !status.Clean()
 	}
-	repo.UncommitedChanges = uncommittedChanges
+
+	branch, err := i.git.GetBranchName(ctx, dir)
+	if err != nil {
+		i.logger.Error(err, "git.GetBranchName failed", "name", name, "lang", lang)
+	}
+	repo.Branch = branch
+
+	commits, err := i.git.GetCommits(ctx, dir, 1)
+	if err != nil {
+		i.logger.Error(err, "git.GetCommits failed", "name", name, "lang", lang)
+	} else if len(commits) > 0 {
+		repo.LastCommit = &commits[0]
+	}
+
+	conflicts, err := i.git.HasMergeConflicts(ctx, dir)
+	if err != nil {
+		i.logger.Error(err, "git.HasMergeConflicts failed", "name", name, "lang", lang)
+	}
+	repo.MergeConflicts = conflicts
 
 	return repo
 }
 
-func (i Interactor) Clone(r Repo) error {
-	parent := filepath.Join(i.baseDir, r.Owner, r.Language)
+func (i Interactor) Clone(ctx context.Context, r Repo) error {
+	parent := filepath.Join(i.baseDir, r.hostDir(), r.Owner, r.Language)
 	if err := i.filesystem.CreateDirectory(parent); err != nil {
 		return fmt.Errorf("fs.CreateDirectory failed: %w", err)
 	}
 
-	return i.git.Clone(r.URL, parent)
+	return i.git.Clone(ctx, r.URL, parent)
 }
 
-func (i Interactor) CreateRepo(ctx context.Context, name string, private bool) (*Repo, error) {
-	if err := i.github.CreateRepo(ctx, name, private); err != nil {
+// CreateRepo creates a remote repo, filed under the language detected in the
+// working tree at dir, or unknownLanguage if dir is "".
+func (i Interactor) CreateRepo(ctx context.Context, name string, private bool, dir string) (*Repo, error) {
+	a := i.primary()
+	r, err := a.provider.CreateRepo(ctx, name, private)
+	if err != nil {
 		return nil, err
 	}
 
-	user := os.Getenv("GITHUB_USERNAME")
-	return &Repo{
-		Name: name,
-		// TODO: dynamically detect target language when running create in a repo
-		Language: "unknown",
-		Owner:    user,
-		URL:      fmt.Sprintf("https://github.com/%s/%s", user, name),
-	}, nil
+	repo := a.normalizeRemote(*r)
+	repo.Language = unknownLanguage
+	if dir != "" {
+		repo.Language = i.detectLanguage(repo, dir)
+	}
+	return &repo, nil
 }
 
 func (i Interactor) DeleteRemote(ctx context.Context, r Repo) error {
 	if err := r.Validate(); err != nil {
 		return fmt.Errorf("invalid repo: %w", err)
 	}
-	return i.github.DeleteRepo(ctx, r.Owner, r.Name)
+	a, err := i.accountFor(r)
+	if err != nil {
+		return err
+	}
+	return a.provider.DeleteRepo(ctx, r.Owner, r.Name)
 }
 
 func (i Interactor) DeleteLocal(r Repo) error {
@@ -256,58 +431,123 @@ func (i Interactor) Exists(r Repo) (bool, error) {
 	return i.filesystem.Exists(r.Path())
 }
 
+// getRemoteRepos lists the repos of every account, a repo visible to several
+// accounts is attributed to the first of them. Accounts or pages that fail
+// are reported in the returned error alongside the repos that were listed.
 func (i Interactor) getRemoteRepos(ctx context.Context) (map[string]Repo, error) {
-	repos, err := i.github.GetAllRepos(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("github.GetAllRepos failed: %w", err)
-	}
+	rv := make(map[string]Repo, 0)
+	errs := make([]error, 0)
+	sem := make(chan struct{}, i.Concurrency())
+	for _, a := range i.accounts {
+		repos, err := a.provider.GetAllRepos(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("provider.GetAllRepos failed for profile %s: %w", a.Name, err))
+		}
 
-	var wg sync.WaitGroup
-	results := make(chan Repo, len(repos))
-	for _, repo := range repos {
-		wg.Add(1)
-		go func(r github.Repository, results chan<- Repo) {
-			defer wg.Done()
-			results <- i.normalizeAndFetchLanguage(ctx, r)
-		}(repo, results)
+		var wg sync.WaitGroup
+		results := make(chan Repo, len(repos))
+		for _, repo := range repos {
+			wg.Add(1)
+			go func(a account, r provider.Repository, results chan<- Repo) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				results <- i.normalizeAndFetchLanguage(ctx, a, r)
+			}(a, repo, results)
+		}
+
+		wg.Wait()
+		close(results)
+
+		for repo := range results {
+			if _, ok := rv[repo.FullName()]; !ok {
+				rv[repo.FullName()] = repo
+			}
+		}
 	}
 
-	wg.Wait()
-	close(results)
+	return rv, errors.Join(errs...)
+}
 
-	rv := make(map[string]Repo, 0)
-	for repo := range results {
-		rv[repo.FullName()] = repo
+func (i Interactor) normalizeAndFetchLanguage(ctx context.Context, a account, r provider.Repository) Repo {
+	normalized := a.normalizeRemote(r)
+	if _, pinned := i.languages.pin(normalized); normalized.Language == "" && !pinned {
+		lang, err := a.provider.GetPrimaryLanguageForRepo(ctx, normalized.Owner, normalized.Name)
+		if err != nil {
+			i.logger.Error(err, "provider.GetPrimaryLanguageForRepo failed", "name", normalized.Name)
+		} else {
+			normalized.Language = lang
+		}
 	}
 
-	return rv, nil
+	normalized.Language = i.languages.resolve(normalized, normalized.Language)
+	return normalized
 }
 
-func (i Interactor) normalizeAndFetchLanguage(ctx context.Context, r github.Repository) Repo {
-	p := strings.Split(r.FullName, "/")
-	name := p[len(p)-1]
+func (a account) normalizeRemote(r provider.Repository) Repo {
+	return Repo{
+		Name:     r.Name,
+		Owner:    r.Owner,
+		Host:     a.provider.Host(),
+		URL:      a.url(r.SshUrl, r.Owner, r.Name),
+		Profile:  a.Name,
+		Language: strings.ToLower(r.Language),
+		Fork:     r.Fork,
+		GitRepo:  true,
+		PushedAt: r.PushedAt,
+		Size:     r.Size,
+	}
+}
 
-	normalized := Repo{
-		Name:    name,
-		URL:     r.SshUrl,
-		Fork:    r.Fork,
-		GitRepo: true,
+// RepoFromArg parses a repo given on the command line as a bare name,
+// <owner>/<name>, an ssh url (git@host:owner/name.git) or a web url. Bare
+// names belong to the primary profile's user and anything without a host to
+// its host. Urls through a profile's ssh host alias map back to its host, urls
+// on a host no active profile owns are an error.
+func (i Interactor) RepoFromArg(arg string) (Repo, error) {
+	a := i.primary()
+	repo := Repo{
+		Name:  arg,
+		Owner: a.Username,
+		Host:  a.Host,
 	}
 
-	if r.Owner != nil {
-		normalized.Owner = r.Owner.Login
+	if u, err := git.ParseUrl(arg); err == nil {
+		repo.Host, repo.Owner, repo.Name = u.Host, u.Owner, u.Name
+	} else if p := strings.Split(strings.Trim(arg, "/"), "/"); len(p) > 1 {
+		repo.Owner, repo.Name = strings.Join(p[:len(p)-1], "/"), p[len(p)-1]
 	}
 
-	lang, err := i.github.GetPrimaryLanguageForRepo(ctx, i.username, name)
+	repo.Name = strings.TrimSuffix(repo.Name, ".git")
+	a, err := i.accountFor(repo)
 	if err != nil {
-		i.logger.Error(err, "github.GetPrimaryLanguageForRepo failed", "name", name)
-	} else {
-		normalized.Language = strings.ToLower(lang)
+		return repo, err
 	}
+	repo.Host, repo.Profile = a.Host, a.Name
 
-	return normalized
+	repo.URL = fmt.Sprintf("https://%s/%s/%s", repo.Host, repo.Owner, repo.Name)
+	if a.SshHost != "" {
+		repo.URL = a.url("", repo.Owner, repo.Name)
+	}
+	return repo, nil
 }
 
-func (i Interactor) GetPrimaryLanguageForRepo(ctx context.Context, owner, name string) (string, error) {
-	return i.github.GetPrimaryLanguageForRepo(ctx, owner, name)
+// GetPrimaryLanguageForRepo returns the language directory r belongs in, see
+// languageMap.resolve.
+func (i Interactor) GetPrimaryLanguageForRepo(ctx context.Context, r Repo) (string, error) {
+	if pin, ok := i.languages.pin(r); ok {
+		return pin, nil
+	}
+
+	a, err := i.accountFor(r)
+	if err != nil {
+		return "", err
+	}
+
+	lang, err := a.provider.GetPrimaryLanguageForRepo(ctx, r.Owner, r.Name)
+	if err != nil {
+		return "", err
+	}
+	return i.languages.resolve(r, lang), nil
 }
@@ -0,0 +1,132 @@
+package interactor
+
+import (
+	"context"
+	"path/filepath"
+	"sgit/filesystem"
+	"testing"
+)
+
+func testInteractor() Interactor {
+	return Interactor{accounts: []account{
+		{Profile: Profile{Name: "personal", Host: "github.com", Username: "me"}},
+		{Profile: Profile{Name: "work", Host: "gitea.example.com", Username: "me-work", SshHost: "gitea-work"}},
+	}}
+}
+
+func TestRepoFromArg(t *testing.T) {
+	tests := []struct {
+		arg, host, owner, name, profile, url string
+		wantErr                              bool
+	}{
+		{arg: "foo", host: "github.com", owner: "me", name: "foo", profile: "personal", url: "https://github.com/me/foo"},
+		{arg: "org/foo", host: "github.com", owner: "org", name: "foo", profile: "personal", url: "https://github.com/org/foo"},
+		{arg: "git@github.com:org/foo.git", host: "github.com", owner: "org", name: "foo", profile: "personal", url: "https://github.com/org/foo"},
+		{arg: "https://gitea.example.com/team/foo", host: "gitea.example.com", owner: "team", name: "foo", profile: "work", url: "git@gitea-work:team/foo.git"},
+		{arg: "git@gitea-work:team/foo.git", host: "gitea.example.com", owner: "team", name: "foo", profile: "work", url: "git@gitea-work:team/foo.git"},
+		{arg: "git@gitlab.com:me/foo.git", wantErr: true},
+		{arg: "https://gitlab.com/me/foo", wantErr: true},
+	}
+
+	i := testInteractor()
+	for _, tt := range tests {
+		r, err := i.RepoFromArg(tt.arg)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("RepoFromArg(%q) error = %v, wantErr %v", tt.arg, err, tt.wantErr)
+			continue
+		} else if tt.wantErr {
+			continue
+		}
+
+		if r.Host != tt.host || r.Owner != tt.owner || r.Name != tt.name || r.Profile != tt.profile || r.URL != tt.url {
+			t.Errorf("RepoFromArg(%q) = %s %s/%s profile %s url %s, want %s %s/%s profile %s url %s",
+				tt.arg, r.Host, r.Owner, r.Name, r.Profile, r.URL, tt.host, tt.owner, tt.name, tt.profile, tt.url)
+		}
+	}
+}
+
+func TestAccountForUnownedHost(t *testing.T) {
+	i := testInteractor()
+
+	if a, err := i.accountFor(Repo{Name: "foo", Owner: "me", Host: "gitlab.com"}); err == nil {
+		t.Errorf("accountFor a gitlab.com repo returned profile %s, want an error", a.Name)
+	}
+
+	a, err := i.accountFor(Repo{Name: "foo", Owner: "other", Host: "gitea.example.com"})
+	if err != nil || a.Name != "work" {
+		t.Errorf("accountFor a gitea.example.com repo = %s, %v, want work", a.Name, err)
+	}
+}
+
+func TestNormalizeNestedOwner(t *testing.T) {
+	tests := []struct {
+		dir, owner, lang, name string
+	}{
+		{dir: "me/go/foo", owner: "me", lang: "go", name: "foo"},
+		{dir: "group/sub/go/foo", owner: "group/sub", lang: "go", name: "foo"},
+		{dir: "group/sub/deeper/rust/foo/", owner: "group/sub/deeper", lang: "rust", name: "foo"},
+	}
+
+	root := t.TempDir()
+	i := testInteractor()
+	i.filesystem = filesystem.New(root)
+	for _, tt := range tests {
+		r := i.normalize(context.Background(), "gitlab.com", root, filepath.Join(root, tt.dir))
+		if r.Owner != tt.owner || r.Language != tt.lang || r.Name != tt.name || r.Host != "gitlab.com" {
+			t.Errorf("normalize(%q) = %s/%s/%s on %s, want %s/%s/%s", tt.dir, r.Owner, r.Language, r.Name, r.Host, tt.owner, tt.lang, tt.name)
+		}
+	}
+}
+
+func TestMatchLocalToRemote(t *testing.T) {
+	repo := func(owner, name, url string) Repo {
+		return Repo{Host: "github.com", Owner: owner, Name: name, URL: url}
+	}
+	byName := func(repos ...Repo) map[string]Repo {
+		rv := make(map[string]Repo, 0)
+		for _, r := range repos {
+			rv[r.FullName()] = r
+		}
+		return rv
+	}
+
+	remotes := byName(
+		repo("new-owner", "moved", "https://github.com/new-owner/moved"),
+		repo("me", "aliased", "https://github.com/me/aliased"),
+		repo("me", "shared", "https://github.com/me/shared"),
+		repo("org", "shared", "https://github.com/org/shared"),
+		repo("org", "both", "https://github.com/org/both"),
+	)
+
+	// filed under its old owner, found by url
+	moved := repo("old-owner", "moved", "git@github.com:new-owner/moved.git")
+	// an ssh host alias doesn't match a url, the directory does
+	aliased := repo("me", "aliased", "git@github-work:me/aliased.git")
+	// sits at me/shared but is a clone of org/shared
+	shared := repo("me", "shared", "git@github.com:org/shared.git")
+	// org/both by directory and by url, the url wins
+	byDir := repo("org", "both", "")
+	byUrl := repo("me", "both-clone", "https://github.com/org/both.git")
+	// matches nothing
+	unknown := repo("me", "unknown", "git@github.com:me/unknown.git")
+
+	got := matchLocalToRemote(byName(moved, aliased, shared, byDir, byUrl, unknown), remotes)
+
+	want := map[string]Repo{
+		"new-owner/moved": moved,
+		"me/aliased":      aliased,
+		"org/shared":      shared,
+		"org/both":        byUrl,
+	}
+	if len(got) != len(want) {
+		t.Errorf("matched %d remotes, want %d: %v", len(got), len(want), got)
+	}
+	for name, local := range want {
+		if got[name].FullName() != local.FullName() {
+			t.Errorf("%s matched local %q, want %q", name, got[name].FullName(), local.FullName())
+		}
+	}
+	if _, ok := got["me/shared"]; ok {
+		t.Error("me/shared matched the clone of org/shared sitting in its directory")
+	}
+}
@@ -0,0 +1,44 @@
+package interactor
+
+import (
+	"sgit/linguist"
+)
+
+// unknownLanguage is the directory repos are filed under when their language
+// can't be told.
+const unknownLanguage = "unknown"
+
+// detectLanguage returns the language directory for r from the working tree
+// at dir, its .sgit file winning over detection, or unknownLanguage if
+// nothing is recognised.
+func (i Interactor) detectLanguage(r Repo, dir string) string {
+	if pin := readRepoPin(dir); pin != "" {
+		return dirName(pin)
+	}
+
+	detected, err := linguist.Detect(dir)
+	if err != nil {
+		i.logger.Error(err, "linguist.Detect failed", "path", dir)
+	}
+
+	if lang := i.languages.resolve(r, detected); lang != "" {
+		return lang
+	}
+	return unknownLanguage
+}
+
+// expectedLocalLanguage returns the language directory a repo without a
+// remote belongs in: its pin if it has one, otherwise the detected language
+// for repos filed under unknownLanguage. Other repos are left where they are.
+func (i Interactor) expectedLocalLanguage(r Repo) string {
+	if pin := readRepoPin(r.Path()); pin != "" {
+		return dirName(pin)
+	}
+	if pin, ok := i.languages.pin(r); ok {
+		return pin
+	}
+	if r.Language == unknownLanguage {
+		return i.detectLanguage(r, r.Path())
+	}
+	return r.Language
+}
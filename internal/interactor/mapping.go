@@ -0,0 +1,78 @@
+package interactor
+
+import (
+	"path/filepath"
+	"sgit/internal/config"
+	"strings"
+)
+
+// repoFile is an optional file in a repo's root, its language setting pins the
+// repo's language directory and wins over the config file.
+const repoFile = ".sgit"
+
+// languageMap turns the language a provider or detection reports into the
+// directory a repo is filed under.
+type languageMap struct {
+	// pins are keyed by lowercase <owner>/<name> or <host>/<owner>/<name>
+	pins    map[string]string
+	aliases map[string]string
+}
+
+func newLanguageMap() languageMap {
+	m := languageMap{make(map[string]string, 0), make(map[string]string, 0)}
+	for repo, lang := range config.Table(config.Repos) {
+		m.pins[strings.ToLower(strings.Trim(repo, "/"))] = lang
+	}
+	for from, to := range config.Table(config.Languages) {
+		m.aliases[strings.ToLower(from)] = to
+	}
+	return m
+}
+
+// resolve returns the language directory for r given the reported language:
+// a pin for the repo if there is one, otherwise the reported language through
+// the aliases, made safe to use as a directory name either way.
+func (m languageMap) resolve(r Repo, reported string) string {
+	if pin, ok := m.pin(r); ok {
+		return pin
+	}
+
+	lang := strings.ToLower(strings.TrimSpace(reported))
+	if alias, ok := m.aliases[lang]; ok {
+		lang = alias
+	}
+	return dirName(lang)
+}
+
+func (m languageMap) pin(r Repo) (string, bool) {
+	for _, key := range []string{r.FullName(), r.Host + "/" + r.Owner + "/" + r.Name, r.Owner + "/" + r.Name} {
+		if pin, ok := m.pins[strings.ToLower(key)]; ok {
+			return dirName(pin), true
+		}
+	}
+	return "", false
+}
+
+// dirReplacer spells out the characters languages use that are awkward in
+// paths: c# -> csharp, c++ -> cpp, vim script -> vim-script.
+var dirReplacer = strings.NewReplacer(
+	"#", "sharp",
+	"+", "p",
+	" ", "-",
+	"/", "-",
+	"\\", "-",
+	"'", "",
+)
+
+func dirName(lang string) string {
+	return dirReplacer.Replace(strings.ToLower(strings.TrimSpace(lang)))
+}
+
+// readRepoPin returns the language pinned by dir's .sgit file, or "".
+func readRepoPin(dir string) string {
+	c, err := config.Read(filepath.Join(dir, repoFile))
+	if err != nil {
+		return ""
+	}
+	return c.Values()["language"]
+}
@@ -2,22 +2,76 @@ package interactor
 
 import (
 	"errors"
-	"os"
 	"path/filepath"
+	"sgit/git"
+	"sgit/github"
+	"sgit/internal/config"
+	"strings"
+	"time"
 )
 
+// States are bit flags, a repo can be in several at once e.g.
+// UncommittedChanges+Ahead+StashPresent.
 const (
-	UpToDate State = iota + 1
+	UpToDate State = 1 << iota
 	UncommittedChanges
 	NotGitRepo
 	NoRemoteRepo
 	IncorrectLanguageParentDirectory
 	NotCloned
+	Ahead
+	Behind
+	Diverged
+	DetachedHead
+	MergeConflicts
+	StashPresent
 )
 
+// AllStates lists every single State flag in display order.
+var AllStates = []State{
+	UpToDate,
+	UncommittedChanges,
+	NotGitRepo,
+	NoRemoteRepo,
+	IncorrectLanguageParentDirectory,
+	NotCloned,
+	Ahead,
+	Behind,
+	Diverged,
+	DetachedHead,
+	MergeConflicts,
+	StashPresent,
+}
+
+// outOfDate are the states that mean a repo is not UpToDate.
+const outOfDate = UncommittedChanges | IncorrectLanguageParentDirectory |
+	Ahead | Behind | Diverged | DetachedHead | MergeConflicts
+
 type Repo struct {
-	Name, Language, Owner, URL       string
+	Name, Language, Owner, URL, Host string
 	Fork, GitRepo, UncommitedChanges bool
+
+	// Profile is the profile a remote repo was listed by.
+	Profile string
+
+	// ExpectedLanguage is the language the repo should be filed under, as
+	// reported by its remote or detected locally, only set when it is filed
+	// under a different one.
+	ExpectedLanguage string
+
+	// PushedAt and Size (in kilobytes) come from the remote repo.
+	PushedAt time.Time
+	Size     int64
+
+	// Remotes, UpstreamURL, Status, Branch, LastCommit and MergeConflicts are
+	// only populated for local git repos. UpstreamURL is set for forks that
+	// track the repo they were forked from.
+	Remotes        []git.Remote
+	UpstreamURL    string
+	Status         git.Status
+	Branch         string
+	LastCommit     *git.Commit
+	MergeConflicts bool
 }
 
 func (r Repo) Validate() error {
@@ -32,13 +86,33 @@ func (r Repo) Validate() error {
 	return nil
 }
 
+// FullName is <owner>/<name>, prefixed with the host for repos not on
+// github.com so the same owner can exist on several hosts.
 func (r Repo) FullName() string {
-	return filepath.Join(r.Owner, r.Name)
+	return filepath.Join(r.hostDir(), r.Owner, r.Name)
 }
 
 func (r Repo) Path() string {
-	baseDir := os.Getenv("CODE_HOME_DIR")
-	return filepath.Join(baseDir, r.Owner, r.Language, r.Name)
+	baseDir := config.Get(config.BaseDir)
+	return filepath.Join(baseDir, r.hostDir(), r.Owner, r.Language, r.Name)
+}
+
+// urlKey identifies the repo by its remote url, see git.Url.Key.
+func (r Repo) urlKey() string {
+	u, err := git.ParseUrl(r.URL)
+	if err != nil {
+		return ""
+	}
+	return u.Key()
+}
+
+// hostDir is the directory beneath CODE_HOME_DIR that holds the repo's owner
+// directories, github.com repos live directly in CODE_HOME_DIR.
+func (r Repo) hostDir() string {
+	if r.Host == github.DefaultHost {
+		return ""
+	}
+	return r.Host
 }
 
 type RepoStatePair struct {
@@ -46,9 +120,35 @@ type RepoStatePair struct {
 	State
 }
 
-type State int
+type State uint
+
+// Has reports whether every flag in t is set in s.
+func (s State) Has(t State) bool {
+	return s&t == t
+}
 
+// Flags splits s into its individual flags.
+func (s State) Flags() []State {
+	rv := make([]State, 0)
+	for _, f := range AllStates {
+		if s.Has(f) {
+			rv = append(rv, f)
+		}
+	}
+	return rv
+}
+
+// String returns the flag names joined with "+", the same syntax the -s flag
+// accepts for requiring several states at once.
 func (s State) String() string {
+	names := make([]string, 0)
+	for _, f := range s.Flags() {
+		names = append(names, f.name())
+	}
+	return strings.Join(names, "+")
+}
+
+func (s State) name() string {
 	switch s {
 	case UpToDate:
 		return "UpToDate"
@@ -62,7 +162,48 @@ func (s State) String() string {
 		return "IncorrectLanguageParentDirectory"
 	case NotCloned:
 		return "NotCloned"
+	case Ahead:
+		return "Ahead"
+	case Behind:
+		return "Behind"
+	case Diverged:
+		return "Diverged"
+	case DetachedHead:
+		return "DetachedHead"
+	case MergeConflicts:
+		return "MergeConflicts"
+	case StashPresent:
+		return "StashPresent"
 	}
 
 	return ""
 }
+
+// localState derives the flags that come from the repo's working tree.
+func localState(r Repo) State {
+	var s State
+	if r.UncommitedChanges {
+		s |= UncommittedChanges
+	}
+
+	switch {
+	case r.Status.Ahead > 0 && r.Status.Behind > 0:
+		s |= Diverged
+	case r.Status.Ahead > 0:
+		s |= Ahead
+	case r.Status.Behind > 0:
+		s |= Behind
+	}
+
+	if r.Status.Detached {
+		s |= DetachedHead
+	}
+	if r.MergeConflicts {
+		s |= MergeConflicts
+	}
+	if r.Status.Stashes > 0 {
+		s |= StashPresent
+	}
+
+	return s
+}
@@ -0,0 +1,103 @@
+package interactor
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// Move relocates a repo filed under the wrong language directory.
+type Move struct {
+	Repo     Repo
+	From, To string
+	// Collision explains why the move can't be made, e.g. To already
+	// exists. Colliding moves are skipped by ApplyMoves.
+	Collision string
+}
+
+// PlanMoves returns a move for every repo in rsps flagged with
+// IncorrectLanguageParentDirectory, sorted by source path. Nothing is touched
+// on disk.
+func (i Interactor) PlanMoves(rsps []RepoStatePair) ([]Move, error) {
+	moves := make([]Move, 0)
+	for _, rsp := range rsps {
+		if !rsp.State.Has(IncorrectLanguageParentDirectory) || rsp.ExpectedLanguage == "" {
+			continue
+		}
+
+		target := rsp.Repo
+		target.Language = rsp.ExpectedLanguage
+		moves = append(moves, Move{Repo: rsp.Repo, From: rsp.Path(), To: target.Path()})
+	}
+
+	sort.Slice(moves, func(a, b int) bool {
+		return moves[a].From < moves[b].From
+	})
+
+	destinations := make(map[string]string, 0)
+	for idx, m := range moves {
+		exists, err := i.filesystem.Exists(m.To)
+		if err != nil {
+			return nil, fmt.Errorf("fs.Exists failed: %w", err)
+		}
+
+		if exists {
+			moves[idx].Collision = "destination already exists"
+		} else if other, ok := destinations[m.To]; ok {
+			moves[idx].Collision = "destination is also the target of " + other
+		} else {
+			destinations[m.To] = m.From
+		}
+	}
+
+	return moves, nil
+}
+
+// ApplyMoves makes every move without a collision. If one fails the moves
+// already made are undone, so the tree is either fully organised or left as
+// it was.
+func (i Interactor) ApplyMoves(moves []Move) error {
+	done := make([]Move, 0)
+	for _, m := range moves {
+		if m.Collision != "" {
+			continue
+		}
+
+		if err := i.move(m.From, m.To); err != nil {
+			err = fmt.Errorf("%s: %w", m.Repo.FullName(), err)
+			return errors.Join(err, i.rollback(done))
+		}
+		done = append(done, m)
+	}
+
+	return nil
+}
+
+func (i Interactor) move(from, to string) error {
+	if err := i.filesystem.CreateDirectory(filepath.Dir(to)); err != nil {
+		return fmt.Errorf("fs.CreateDirectory failed: %w", err)
+	}
+
+	if err := i.filesystem.MoveDir(from, to); err != nil {
+		return fmt.Errorf("fs.MoveDir failed: %w", err)
+	}
+
+	// drop the language directory if this was its last repo
+	if err := i.filesystem.PruneDir(filepath.Dir(from)); err != nil {
+		i.logger.Error(err, "fs.PruneDir failed", "path", filepath.Dir(from))
+	}
+	return nil
+}
+
+// rollback undoes moves in reverse order, reporting the ones it couldn't.
+func (i Interactor) rollback(moves []Move) error {
+	errs := make([]error, 0)
+	for idx := len(moves) - 1; idx >= 0; idx-- {
+		m := moves[idx]
+		if err := i.move(m.To, m.From); err != nil {
+			errs = append(errs, fmt.Errorf("rollback of %s failed, it is at %s: %w", m.Repo.FullName(), m.To, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,149 @@
+package interactor
+
+import (
+	"os"
+	"path/filepath"
+	"sgit/filesystem"
+	"sgit/internal/logging"
+	"strings"
+	"testing"
+)
+
+// organizeTree returns an interactor over a temporary base directory holding
+// a repo with a file at each <owner>/<lang>/<name> path.
+func organizeTree(t *testing.T, paths ...string) (Interactor, string) {
+	t.Helper()
+	base := t.TempDir()
+	t.Setenv("CODE_HOME_DIR", base)
+	t.Setenv("SGIT_CONFIG", filepath.Join(base, "missing.toml"))
+
+	for _, p := range paths {
+		mkRepo(t, filepath.Join(base, p))
+	}
+
+	i := testInteractor()
+	i.logger = logging.New()
+	i.filesystem = filesystem.New(base)
+	i.baseDir = base
+	return i, base
+}
+
+func mkRepo(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte(dir), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func misfiled(owner, lang, name, expected string) RepoStatePair {
+	return RepoStatePair{
+		Repo:  Repo{Host: "github.com", Owner: owner, Name: name, Language: lang, ExpectedLanguage: expected},
+		State: NoRemoteRepo | IncorrectLanguageParentDirectory,
+	}
+}
+
+func assertExists(t *testing.T, base string, paths ...string) {
+	t.Helper()
+	for _, p := range paths {
+		if _, err := os.Stat(filepath.Join(base, p)); err != nil {
+			t.Errorf("%s is missing: %v", p, err)
+		}
+	}
+}
+
+func assertMissing(t *testing.T, base string, paths ...string) {
+	t.Helper()
+	for _, p := range paths {
+		if _, err := os.Stat(filepath.Join(base, p)); !os.IsNotExist(err) {
+			t.Errorf("%s exists, want it gone: %v", p, err)
+		}
+	}
+}
+
+func TestPlanMovesSkipsExistingDestination(t *testing.T) {
+	i, base := organizeTree(t, "me/go/foo", "me/rust/foo", "me/go/bar")
+
+	moves, err := i.PlanMoves([]RepoStatePair{
+		misfiled("me", "go", "foo", "rust"),
+		misfiled("me", "go", "bar", "rust"),
+		{Repo: Repo{Host: "github.com", Owner: "me", Name: "ok", Language: "go"}, State: UpToDate},
+	})
+	if err != nil {
+		t.Fatalf("PlanMoves failed: %v", err)
+	}
+	if len(moves) != 2 {
+		t.Fatalf("planned %d moves, want 2", len(moves))
+	}
+
+	// sorted by source, bar before foo
+	if moves[0].Collision != "" || moves[0].To != filepath.Join(base, "me/rust/bar") {
+		t.Errorf("bar move = %+v, want it to me/rust/bar", moves[0])
+	}
+	if moves[1].Collision != "destination already exists" {
+		t.Errorf("foo collision = %q, want destination already exists", moves[1].Collision)
+	}
+
+	if err := i.ApplyMoves(moves); err != nil {
+		t.Fatalf("ApplyMoves failed: %v", err)
+	}
+	assertExists(t, base, "me/go/foo/README", "me/rust/foo/README", "me/rust/bar/README")
+	assertMissing(t, base, "me/go/bar")
+}
+
+func TestPlanMovesSharedDestination(t *testing.T) {
+	i, base := organizeTree(t, "me/go/foo", "me/python/foo")
+
+	moves, err := i.PlanMoves([]RepoStatePair{
+		misfiled("me", "python", "foo", "rust"),
+		misfiled("me", "go", "foo", "rust"),
+	})
+	if err != nil {
+		t.Fatalf("PlanMoves failed: %v", err)
+	}
+	if len(moves) != 2 {
+		t.Fatalf("planned %d moves, want 2", len(moves))
+	}
+	if moves[0].Collision != "" {
+		t.Errorf("first move collides: %s", moves[0].Collision)
+	}
+	if !strings.Contains(moves[1].Collision, moves[0].From) {
+		t.Errorf("second collision = %q, want it to name %s", moves[1].Collision, moves[0].From)
+	}
+
+	if err := i.ApplyMoves(moves); err != nil {
+		t.Fatalf("ApplyMoves failed: %v", err)
+	}
+	assertExists(t, base, "me/rust/foo/README", "me/python/foo/README")
+	// the emptied language directory is pruned
+	assertMissing(t, base, "me/go")
+}
+
+func TestApplyMovesRollsBack(t *testing.T) {
+	i, base := organizeTree(t, "me/c/a", "me/go/b", "me/go/c")
+
+	moves, err := i.PlanMoves([]RepoStatePair{
+		misfiled("me", "c", "a", "rust"),
+		misfiled("me", "go", "b", "rust"),
+		misfiled("me", "go", "c", "rust"),
+	})
+	if err != nil {
+		t.Fatalf("PlanMoves failed: %v", err)
+	}
+
+	// something appears at b's destination after planning, failing the
+	// second move once the first has been made
+	mkRepo(t, filepath.Join(base, "me/rust/b"))
+
+	err = i.ApplyMoves(moves)
+	if err == nil || !strings.Contains(err.Error(), "me/b") {
+		t.Fatalf("ApplyMoves returned %v, want the me/b move to fail", err)
+	}
+
+	// a is back where it was, including the language directory pruned when
+	// it moved, and nothing after the failure was moved
+	assertExists(t, base, "me/c/a/README", "me/go/b/README", "me/go/c/README", "me/rust/b/README")
+	assertMissing(t, base, "me/rust/a", "me/rust/c")
+}
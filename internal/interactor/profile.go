@@ -0,0 +1,195 @@
+package interactor
+
+import (
+	"context"
+	"fmt"
+	"sgit/git"
+	"sgit/gitea"
+	"sgit/github"
+	"sgit/gitlab"
+	"sgit/internal/config"
+	"sgit/internal/credentials"
+	"sgit/internal/logging"
+	"sgit/provider"
+	"strings"
+	"time"
+)
+
+// defaultProfile is made up of the top-level settings, it is only used when
+// the config file defines no [profile.<name>] tables.
+const defaultProfile = "default"
+
+// Profile is an account on a hosting provider. Repos of every active profile
+// are merged into the same <host>/<owner>/<lang>/<name> tree.
+type Profile struct {
+	Name, Provider, Url, Host, Username, SshHost string
+	tokenKey                                     string
+	// affiliations, orgs and requestBudget scope and limit GitHub profiles
+	affiliations, orgs, requestBudget string
+}
+
+// ActiveProfiles returns the profiles selected by the profiles setting (or
+// --profile), every profile when it is unset. The first one is used for
+// anything that isn't tied to an existing repo, such as create and init.
+func ActiveProfiles() []Profile {
+	names := config.ProfileNames()
+	if len(names) == 0 {
+		return []Profile{resolveHost(topLevelProfile())}
+	}
+
+	if active := config.Get(config.Profiles); active != "" {
+		names = parseCommaSeparate(active)
+	}
+
+	rv := make([]Profile, 0)
+	for _, name := range names {
+		p := Profile{
+			Name:     name,
+			Provider: config.Get(config.ProfileKey(name, config.ProfileProvider)),
+			Url:      config.Get(config.ProfileKey(name, config.ProfileUrl)),
+			Host:     config.Get(config.ProfileKey(name, config.ProfileHost)),
+			Username: config.Get(config.ProfileKey(name, config.ProfileUsername)),
+			SshHost:  config.Get(config.ProfileKey(name, config.ProfileSshHost)),
+			tokenKey: config.ProfileKey(name, config.ProfileToken),
+
+			affiliations:  profileSetting(name, config.ProfileAffiliations, config.GithubAffiliations),
+			orgs:          profileSetting(name, config.ProfileOrgs, config.GithubOrgs),
+			requestBudget: profileSetting(name, config.ProfileRequestBudget, config.GithubRequestBudget),
+		}
+		if p.Provider == "" {
+			p.Provider = config.Get(config.Provider)
+		}
+
+		rv = append(rv, resolveHost(p))
+	}
+
+	return rv
+}
+
+// profileSetting returns a profile's field, falling back to the top-level
+// setting when the profile doesn't set it.
+func profileSetting(name, field, fallback string) string {
+	if v := config.Get(config.ProfileKey(name, field)); v != "" {
+		return v
+	}
+	return config.Get(fallback)
+}
+
+func topLevelProfile() Profile {
+	p := Profile{
+		Name:     defaultProfile,
+		Provider: config.Get(config.Provider),
+		Username: config.Get(config.Username),
+
+		affiliations:  config.Get(config.GithubAffiliations),
+		orgs:          config.Get(config.GithubOrgs),
+		requestBudget: config.Get(config.GithubRequestBudget),
+	}
+
+	switch p.Provider {
+	case provider.Gitlab:
+		p.Url, p.tokenKey = config.Get(config.GitlabUrl), config.GitlabToken
+	case provider.Gitea, provider.Forgejo:
+		p.Url, p.tokenKey = config.Get(config.GiteaUrl), config.GiteaToken
+	default:
+		p.Url, p.Host, p.tokenKey = config.Get(config.GithubApiUrl), config.Get(config.GithubHost), config.GithubToken
+		if p.Username == "" {
+			p.Username = config.Get(config.GithubUsername)
+		}
+	}
+
+	return p
+}
+
+// resolveHost fills in the host the provider derives from the url when none
+// is configured.
+func resolveHost(p Profile) Profile {
+	if b, err := p.build(logging.New(), ""); err == nil {
+		p.Host = b.Host()
+	}
+	return p
+}
+
+// Credential finds the profile's token, see credentials.Resolve.
+func (p Profile) Credential(ctx context.Context) (*credentials.Credential, error) {
+	cred, err := credentials.Resolve(ctx, git.New(), p.Host, p.Username, p.tokenKey)
+	if err != nil {
+		return nil, fmt.Errorf("credentials.Resolve failed: %w", err)
+	}
+	return cred, nil
+}
+
+func (p Profile) build(logger *logging.Logger, token string) (provider.Provider, error) {
+	switch p.Provider {
+	case provider.Gitlab:
+		return gitlab.New(token, p.Url), nil
+	case provider.Gitea, provider.Forgejo:
+		g, err := gitea.New(token, p.Url)
+		if err != nil {
+			return nil, fmt.Errorf("gitea.New failed: %w", err)
+		}
+		return g, nil
+	}
+
+	gh := github.New(token, p.Username)
+	if err := gh.SetEndpoints(p.Url, p.Host); err != nil {
+		logger.Error(err, "invalid github api url, using default", "profile", p.Name)
+	}
+
+	if v := p.requestBudget; v != "" {
+		budget, err := time.ParseDuration(v)
+		if err != nil {
+			logger.Error(err, "invalid request_budget, using default", "profile", p.Name, "value", v)
+		} else {
+			gh.SetRequestBudget(budget)
+		}
+	}
+
+	if err := gh.SetScope(parseCommaSeparate(p.affiliations), parseCommaSeparate(p.orgs)); err != nil {
+		logger.Error(err, "invalid affiliations, using default", "profile", p.Name)
+	}
+
+	return gh, nil
+}
+
+// account is a profile with its provider authenticated.
+type account struct {
+	Profile
+	provider provider.Provider
+}
+
+func newAccount(ctx context.Context, logger *logging.Logger, p Profile) (*account, error) {
+	cred, err := p.Credential(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := p.build(logger, cred.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &account{p, b}
+	if a.Username == "" {
+		a.Username, err = a.provider.GetUsername(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("provider.GetUsername failed: %w", err)
+		}
+	}
+
+	return a, nil
+}
+
+// url is the url to clone owner/name through, going through the profile's
+// ssh host alias when it has one.
+func (a account) url(sshUrl, owner, name string) string {
+	if a.SshHost == "" {
+		return sshUrl
+	}
+	return fmt.Sprintf("git@%s:%s/%s.git", a.SshHost, owner, name)
+}
+
+// owns reports whether host is the profile's host or its ssh alias.
+func (a account) owns(host string) bool {
+	return strings.EqualFold(host, a.Host) || (a.SshHost != "" && strings.EqualFold(host, a.SshHost))
+}
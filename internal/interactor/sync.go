@@ -0,0 +1,74 @@
+package interactor
+
+import (
+	"context"
+	"fmt"
+	"sgit/git"
+)
+
+type SyncResult string
+
+const (
+	Pulled          SyncResult = "pulled"
+	Pushed          SyncResult = "pushed"
+	InSync          SyncResult = "up to date"
+	SkippedDirty    SyncResult = "skipped: uncommitted changes"
+	SkippedDiverged SyncResult = "skipped: diverged"
+	SkippedDetached SyncResult = "skipped: detached HEAD"
+	SkippedConflict SyncResult = "skipped: merge conflicts"
+	SkippedNoRemote SyncResult = "skipped: no upstream branch"
+	SkippedNotLocal SyncResult = "skipped: not cloned"
+	Failed          SyncResult = "failed"
+)
+
+// Sync fetches r and then fast-forwards it if it is behind its upstream or
+// pushes it if it is ahead. Repos that need a human (dirty, diverged,
+// detached or conflicted) are left alone.
+func (i Interactor) Sync(ctx context.Context, r Repo) (SyncResult, error) {
+	if !r.GitRepo || r.Status.Oid == "" {
+		return SkippedNotLocal, nil
+	}
+
+	if err := i.git.Fetch(ctx, r.Path()); err != nil {
+		return Failed, fmt.Errorf("git.Fetch failed: %w", err)
+	}
+
+	status, err := i.git.Status(ctx, r.Path())
+	if err != nil {
+		return Failed, fmt.Errorf("git.Status failed: %w", err)
+	}
+
+	switch {
+	case status.Conflicted > 0 || r.MergeConflicts:
+		return SkippedConflict, nil
+	case status.Detached:
+		return SkippedDetached, nil
+	case status.Upstream == "":
+		return SkippedNoRemote, nil
+	case !status.Clean():
+		return SkippedDirty, nil
+	case status.Ahead > 0 && status.Behind > 0:
+		return SkippedDiverged, nil
+	case status.Behind > 0:
+		if err := i.git.PullLatest(ctx, r.Path()); err != nil {
+			return Failed, fmt.Errorf("git.PullLatest failed: %w", err)
+		}
+		return Pulled, nil
+	case status.Ahead > 0:
+		if err := i.git.Push(ctx, r.Path()); err != nil {
+			return Failed, fmt.Errorf("git.Push failed: %w", err)
+		}
+		return Pushed, nil
+	}
+
+	return InSync, nil
+}
+
+// Wip pushes a snapshot of r's uncommitted work to a wip branch, see
+// git.PushLocalChanges. Returns the pushed branch, or "" if r is clean.
+func (i Interactor) Wip(ctx context.Context, r Repo, opts git.WipOptions) (string, error) {
+	if !r.GitRepo {
+		return "", nil
+	}
+	return i.git.PushLocalChanges(ctx, r.Path(), opts)
+}
@@ -17,8 +17,8 @@ func New() *Logger {
 	return &Logger{
 		info:   log.New(os.Stdout, "", 0),
 		debug:  log.New(os.Stdout, "", 0),
-		warn:   log.New(os.Stdout, "", 0),
-		err:    log.New(os.Stdout, "", 0),
+		warn:   log.New(os.Stderr, "", 0),
+		err:    log.New(os.Stderr, "", 0),
 		fields: make(map[string]string, 0),
 	}
 }
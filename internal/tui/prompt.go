@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+var (
+	// ErrDeclined is returned when the user answers no to a confirmation.
+	ErrDeclined = errors.New("declined")
+	// ErrNotInteractive is returned when a prompt is needed but stdin is not
+	// a terminal, flags must supply the answer instead.
+	ErrNotInteractive = errors.New("stdin is not a terminal")
+
+	stdin     *bufio.Reader
+	stdinOnce sync.Once
+)
+
+func IsInteractive() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// Confirm asks a y/n question until it gets a valid answer.
+func Confirm(msg string) (bool, error) {
+	input, err := Ask(msg+" (y/n): ", "Invalid input. Please enter y or n.", func(s string) bool {
+		s = strings.ToLower(s)
+		return s == "y" || s == "n"
+	})
+	return strings.ToLower(input) == "y", err
+}
+
+// Ask prints msg and reads lines until valid accepts one, printing invalidMsg
+// after each rejected line. The accepted line is returned trimmed.
+func Ask(msg, invalidMsg string, valid func(string) bool) (string, error) {
+	if !IsInteractive() {
+		return "", fmt.Errorf("%w, pass the answer to \"%s\" as a flag", ErrNotInteractive, strings.TrimSpace(msg))
+	}
+
+	stdinOnce.Do(func() {
+		stdin = bufio.NewReader(os.Stdin)
+	})
+
+	for {
+		d := color.New(color.FgGreen, color.Bold)
+		d.Print(msg)
+
+		input, err := stdin.ReadString('\n')
+		if err != nil && !(errors.Is(err, io.EOF) && len(input) > 0) {
+			fmt.Println()
+			return "", fmt.Errorf("failed to read answer: %w", err)
+		}
+
+		input = strings.TrimSpace(input)
+		if valid(input) {
+			return input, nil
+		}
+
+		fmt.Println(invalidMsg)
+	}
+}
@@ -30,7 +30,7 @@ func Output(repos []interactor.Repo) {
 
 		d = color.New(color.FgWhite)
 		d.Println(
-			fmt.Sprintf("%s/%s ", repo.Owner, repo.Name),
+			fmt.Sprintf("%s/%s/%s ", repo.Host, repo.Owner, repo.Name),
 		)
 	}
 }
@@ -0,0 +1,157 @@
+package linguist
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreRule is one line of a .gitignore, compiled to a regexp over paths
+// relative to the directory holding the .gitignore.
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreList is the rules in effect for a directory: its own .gitignore's on
+// top of its parents'. Later rules win, as in git.
+type ignoreList struct {
+	base  string
+	rules []ignoreRule
+	// parent holds the rules of enclosing directories
+	parent *ignoreList
+}
+
+// readIgnoreFile parses the .gitignore style file at path, a missing file
+// has no rules.
+func readIgnoreFile(path string) []ignoreRule {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	rules := make([]ignoreRule, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if r, ok := parseIgnoreLine(scanner.Text()); ok {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+func parseIgnoreLine(line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	r := ignoreRule{}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+
+	// a slash anywhere but the end anchors the pattern to the .gitignore's
+	// directory, otherwise it matches at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	expr := globToRegexp(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	r.re = re
+	return r, true
+}
+
+// globToRegexp translates gitignore's glob syntax: * and ? stay within a
+// path segment, ** spans segments and [...] is a character class.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*' && strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case c == '*' && strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i += 1
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i += 1
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// child returns the list for dir, a subdirectory of l's directory, adding
+// dir's own .gitignore.
+func (l *ignoreList) child(dir string) *ignoreList {
+	rules := readIgnoreFile(filepath.Join(dir, ".gitignore"))
+	if len(rules) == 0 {
+		return l
+	}
+	return &ignoreList{dir, rules, l}
+}
+
+// ignored reports whether path is ignored, the last matching rule of the
+// innermost .gitignore with a match deciding.
+func (l *ignoreList) ignored(path string, isDir bool) bool {
+	for list := l; list != nil; list = list.parent {
+		rel, err := filepath.Rel(list.base, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+
+		for i := len(list.rules) - 1; i >= 0; i-- {
+			r := list.rules[i]
+			if r.dirOnly && !isDir {
+				continue
+			}
+			if r.re.MatchString(rel) {
+				return !r.negate
+			}
+		}
+	}
+	return false
+}
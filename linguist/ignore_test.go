@@ -0,0 +1,109 @@
+package linguist
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseIgnoreLine(t *testing.T) {
+	for _, line := range []string{"", "   ", "# comment", "/", "!"} {
+		if _, ok := parseIgnoreLine(line); ok {
+			t.Errorf("parseIgnoreLine(%q) is a rule, want it skipped", line)
+		}
+	}
+}
+
+func TestIgnored(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{name: "extension", rules: []string{"*.log"}, path: "a.log", want: true},
+		{name: "extension at any depth", rules: []string{"*.log"}, path: "a/b/c.log", want: true},
+		{name: "star within a segment", rules: []string{"*.log"}, path: "a.log/b.txt", want: false},
+		{name: "trailing spaces", rules: []string{"a.txt  "}, path: "a.txt", want: true},
+		{name: "leading slash anchors", rules: []string{"/build"}, path: "build", isDir: true, want: true},
+		{name: "leading slash anchors nested", rules: []string{"/build"}, path: "src/build", isDir: true, want: false},
+		{name: "inner slash anchors", rules: []string{"docs/*.md"}, path: "docs/a.md", want: true},
+		{name: "inner slash anchors nested", rules: []string{"docs/*.md"}, path: "x/docs/a.md", want: false},
+		{name: "star stops at slash", rules: []string{"docs/*.md"}, path: "docs/sub/a.md", want: false},
+		{name: "unanchored dir name", rules: []string{"gen"}, path: "a/b/gen", isDir: true, want: true},
+		{name: "leading double star", rules: []string{"**/gen"}, path: "gen", isDir: true, want: true},
+		{name: "leading double star nested", rules: []string{"**/gen"}, path: "a/b/gen", isDir: true, want: true},
+		{name: "trailing double star", rules: []string{"logs/**"}, path: "logs/a/b.txt", want: true},
+		{name: "trailing double star outside", rules: []string{"logs/**"}, path: "logs", isDir: true, want: false},
+		{name: "inner double star none", rules: []string{"a/**/b"}, path: "a/b", want: true},
+		{name: "inner double star many", rules: []string{"a/**/b"}, path: "a/x/y/b", want: true},
+		{name: "question mark", rules: []string{"file?.txt"}, path: "file1.txt", want: true},
+		{name: "question mark is one char", rules: []string{"file?.txt"}, path: "file10.txt", want: false},
+		{name: "class", rules: []string{"[ab].txt"}, path: "a.txt", want: true},
+		{name: "class miss", rules: []string{"[ab].txt"}, path: "c.txt", want: false},
+		{name: "negated class", rules: []string{"[!ab].txt"}, path: "c.txt", want: true},
+		{name: "escaped hash", rules: []string{`\#notes`}, path: "#notes", want: true},
+		{name: "dir only matches dir", rules: []string{"out/"}, path: "out", isDir: true, want: true},
+		{name: "dir only skips file", rules: []string{"out/"}, path: "out", want: false},
+		{name: "negation", rules: []string{"*.log", "!keep.log"}, path: "keep.log", want: false},
+		{name: "negation leaves others", rules: []string{"*.log", "!keep.log"}, path: "other.log", want: true},
+		{name: "later rule wins", rules: []string{"!keep.log", "*.log"}, path: "keep.log", want: true},
+		{name: "no rules", rules: nil, path: "a.go", want: false},
+	}
+
+	base := filepath.Join(string(filepath.Separator), "repo")
+	for _, tt := range tests {
+		rules := make([]ignoreRule, 0)
+		for _, line := range tt.rules {
+			r, ok := parseIgnoreLine(line)
+			if !ok {
+				t.Fatalf("%s: parseIgnoreLine(%q) isn't a rule", tt.name, line)
+			}
+			rules = append(rules, r)
+		}
+
+		l := &ignoreList{base, rules, nil}
+		if got := l.ignored(filepath.Join(base, tt.path), tt.isDir); got != tt.want {
+			t.Errorf("%s: ignored(%q) with %q = %t, want %t", tt.name, tt.path, tt.rules, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoredNested(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, root, ".gitignore", "*.tmp\nbuild/\n")
+	writeFile(t, sub, ".gitignore", "# keep this one\n!keep.tmp\n/local.txt\n")
+
+	top := (&ignoreList{root, nil, nil}).child(root)
+	nested := top.child(sub)
+	if nested == top {
+		t.Fatal("sub/.gitignore wasn't read")
+	}
+
+	tests := []struct {
+		list  *ignoreList
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{list: top, path: "a.tmp", want: true},
+		{list: top, path: "keep.tmp", want: true},
+		{list: top, path: "local.txt", want: false},
+		{list: nested, path: "sub/a.tmp", want: true},
+		{list: nested, path: "sub/keep.tmp", want: false},
+		{list: nested, path: "sub/local.txt", want: true},
+		{list: nested, path: "sub/deeper/local.txt", want: false},
+		{list: nested, path: "sub/build", isDir: true, want: true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.list.ignored(filepath.Join(root, tt.path), tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q) = %t, want %t", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package linguist
+
+// extensions maps lowercase file extensions to GitHub's language names. Only
+// programming and markup languages are listed, data and prose files such as
+// json, yaml and markdown don't count towards a repo's language, as on
+// GitHub.
+var extensions = map[string]string{
+	".c":       "C",
+	".h":       "C",
+	".cc":      "C++",
+	".cpp":     "C++",
+	".cxx":     "C++",
+	".hh":      "C++",
+	".hpp":     "C++",
+	".hxx":     "C++",
+	".cs":      "C#",
+	".csx":     "C#",
+	".fs":      "F#",
+	".fsx":     "F#",
+	".go":      "Go",
+	".rs":      "Rust",
+	".py":      "Python",
+	".pyi":     "Python",
+	".pyx":     "Cython",
+	".ipynb":   "Jupyter Notebook",
+	".js":      "JavaScript",
+	".mjs":     "JavaScript",
+	".cjs":     "JavaScript",
+	".jsx":     "JavaScript",
+	".ts":      "TypeScript",
+	".tsx":     "TypeScript",
+	".mts":     "TypeScript",
+	".cts":     "TypeScript",
+	".vue":     "Vue",
+	".svelte":  "Svelte",
+	".html":    "HTML",
+	".htm":     "HTML",
+	".css":     "CSS",
+	".scss":    "SCSS",
+	".sass":    "Sass",
+	".less":    "Less",
+	".java":    "Java",
+	".kt":      "Kotlin",
+	".kts":     "Kotlin",
+	".scala":   "Scala",
+	".sc":      "Scala",
+	".groovy":  "Groovy",
+	".gradle":  "Groovy",
+	".clj":     "Clojure",
+	".cljs":    "Clojure",
+	".cljc":    "Clojure",
+	".rb":      "Ruby",
+	".rake":    "Ruby",
+	".gemspec": "Ruby",
+	".php":     "PHP",
+	".pl":      "Perl",
+	".pm":      "Perl",
+	".swift":   "Swift",
+	".m":       "Objective-C",
+	".mm":      "Objective-C++",
+	".dart":    "Dart",
+	".lua":     "Lua",
+	".vim":     "Vim Script",
+	".el":      "Emacs Lisp",
+	".sh":      "Shell",
+	".bash":    "Shell",
+	".zsh":     "Shell",
+	".ksh":     "Shell",
+	".fish":    "fish",
+	".ps1":     "PowerShell",
+	".psm1":    "PowerShell",
+	".bat":     "Batchfile",
+	".cmd":     "Batchfile",
+	".hs":      "Haskell",
+	".lhs":     "Haskell",
+	".ml":      "OCaml",
+	".mli":     "OCaml",
+	".ex":      "Elixir",
+	".exs":     "Elixir",
+	".erl":     "Erlang",
+	".hrl":     "Erlang",
+	".elm":     "Elm",
+	".zig":     "Zig",
+	".nim":     "Nim",
+	".jl":      "Julia",
+	".r":       "R",
+	".tf":      "HCL",
+	".hcl":     "HCL",
+	".nix":     "Nix",
+	".sol":     "Solidity",
+	".cr":      "Crystal",
+	".pas":     "Pascal",
+	".f90":     "Fortran",
+	".f":       "Fortran",
+	".asm":     "Assembly",
+	".s":       "Assembly",
+	".cmake":   "CMake",
+	".mk":      "Makefile",
+	".tex":     "TeX",
+	".gd":      "GDScript",
+	".tcl":     "Tcl",
+	".awk":     "Awk",
+	".coffee":  "CoffeeScript",
+	".purs":    "PureScript",
+	".rkt":     "Racket",
+	".scm":     "Scheme",
+	".lisp":    "Common Lisp",
+}
+
+// filenames maps whole file names, matched case-sensitively, to languages for
+// files whose extension says nothing.
+var filenames = map[string]string{
+	"Makefile":       "Makefile",
+	"GNUmakefile":    "Makefile",
+	"makefile":       "Makefile",
+	"CMakeLists.txt": "CMake",
+	"Dockerfile":     "Dockerfile",
+	"Containerfile":  "Dockerfile",
+	"Jenkinsfile":    "Groovy",
+	"Rakefile":       "Ruby",
+	"Gemfile":        "Ruby",
+	"Podfile":        "Ruby",
+	"Vagrantfile":    "Ruby",
+	"Brewfile":       "Ruby",
+	"BUILD.bazel":    "Starlark",
+	"WORKSPACE":      "Starlark",
+	"Tiltfile":       "Starlark",
+	".vimrc":         "Vim Script",
+	".gvimrc":        "Vim Script",
+	"vimrc":          "Vim Script",
+	".bashrc":        "Shell",
+	".bash_profile":  "Shell",
+	".bash_aliases":  "Shell",
+	".profile":       "Shell",
+	".zshrc":         "Shell",
+	".zshenv":        "Shell",
+	".zprofile":      "Shell",
+	".emacs":         "Emacs Lisp",
+}
+
+// interpreters maps the program named by a shebang line to a language.
+var interpreters = map[string]string{
+	"sh":      "Shell",
+	"bash":    "Shell",
+	"zsh":     "Shell",
+	"ksh":     "Shell",
+	"dash":    "Shell",
+	"fish":    "fish",
+	"python":  "Python",
+	"python2": "Python",
+	"python3": "Python",
+	"node":    "JavaScript",
+	"nodejs":  "JavaScript",
+	"deno":    "TypeScript",
+	"ts-node": "TypeScript",
+	"ruby":    "Ruby",
+	"perl":    "Perl",
+	"php":     "PHP",
+	"lua":     "Lua",
+	"awk":     "Awk",
+	"gawk":    "Awk",
+	"Rscript": "R",
+	"tclsh":   "Tcl",
+	"pwsh":    "PowerShell",
+	"elixir":  "Elixir",
+	"escript": "Erlang",
+	"groovy":  "Groovy",
+	"scala":   "Scala",
+	"julia":   "Julia",
+}
+
+// vendoredDirs are directories holding third party or build output, which
+// GitHub leaves out of a repo's languages.
+var vendoredDirs = map[string]struct{}{
+	"vendor":           {},
+	"node_modules":     {},
+	"bower_components": {},
+	"third_party":      {},
+	"thirdparty":       {},
+	"3rdparty":         {},
+	"Godeps":           {},
+	"Pods":             {},
+	"Carthage":         {},
+	"dist":             {},
+	"target":           {},
+	"__pycache__":      {},
+	".venv":            {},
+	"venv":             {},
+	".tox":             {},
+	"site-packages":    {},
+	"deps":             {},
+	"_build":           {},
+	".gradle":          {},
+	".next":            {},
+	".nuxt":            {},
+	".yarn":            {},
+	"coverage":         {},
+}
+
+// generatedSuffixes mark files produced by tools rather than written by hand.
+var generatedSuffixes = []string{
+	".min.js",
+	".min.css",
+	".pb.go",
+	"_pb2.py",
+	"_pb2_grpc.py",
+	".pb.cc",
+	".pb.h",
+	"_generated.go",
+	".generated.cs",
+	".designer.cs",
+	".g.dart",
+	".freezed.dart",
+}
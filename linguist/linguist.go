@@ -0,0 +1,198 @@
+// Package linguist guesses the language of a working tree the way GitHub's
+// linguist does: files are classified by name, extension and shebang,
+// ignored, vendored and generated files are left out and the language with
+// the most bytes wins.
+package linguist
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// headSize is how much of a file is read to find a shebang or a generated
+// code marker.
+const headSize = 512
+
+// Detect returns the primary language of the working tree at dir using
+// GitHub's names, e.g. "Go" or "Vim Script", or "" if no file is recognised.
+func Detect(dir string) (string, error) {
+	sizes, err := Breakdown(dir)
+	if err != nil {
+		return "", err
+	}
+
+	langs := make([]string, 0, len(sizes))
+	for lang := range sizes {
+		langs = append(langs, lang)
+	}
+	if len(langs) == 0 {
+		return "", nil
+	}
+
+	// ties go to the first name alphabetically so results are stable
+	sort.Slice(langs, func(a, b int) bool {
+		if sizes[langs[a]] != sizes[langs[b]] {
+			return sizes[langs[a]] > sizes[langs[b]]
+		}
+		return langs[a] < langs[b]
+	})
+	return langs[0], nil
+}
+
+// Breakdown returns the number of bytes per language in the working tree at
+// dir.
+func Breakdown(dir string) (map[string]int64, error) {
+	if _, err := os.ReadDir(dir); err != nil {
+		return nil, fmt.Errorf("os.ReadDir failed: %w", err)
+	}
+
+	root := &ignoreList{dir, readIgnoreFile(filepath.Join(dir, ".git", "info", "exclude")), nil}
+	sizes := make(map[string]int64, 0)
+	walk(dir, root.child(dir), sizes)
+	return sizes, nil
+}
+
+// walk adds the size of every file under dir to sizes, unreadable entries
+// are skipped.
+func walk(dir string, ignores *ignoreList, sizes map[string]int64) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if e.Type()&os.ModeSymlink != 0 || ignores.ignored(path, e.IsDir()) {
+			continue
+		}
+
+		if e.IsDir() {
+			if skipDir(path, e.Name()) {
+				continue
+			}
+			walk(path, ignores.child(path), sizes)
+			continue
+		}
+
+		if !e.Type().IsRegular() || isGeneratedName(e.Name()) {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil || info.Size() == 0 {
+			continue
+		}
+
+		if lang := classify(path, e.Name()); lang != "" {
+			sizes[lang] += info.Size()
+		}
+	}
+}
+
+// skipDir reports whether the directory at path holds no code of the repo's
+// own: git's metadata, vendored code and nested repos such as submodules.
+func skipDir(path, name string) bool {
+	if name == ".git" {
+		return true
+	}
+	if _, ok := vendoredDirs[name]; ok {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(path, ".git"))
+	return err == nil
+}
+
+func isGeneratedName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, suffix := range generatedSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// classify returns the language of the file at path by its name, then its
+// extension and finally its shebang, or "" if it isn't code or was
+// generated.
+func classify(path, name string) string {
+	lang, ok := filenames[name]
+	if !ok {
+		lang, ok = extensions[strings.ToLower(filepath.Ext(name))]
+	}
+
+	// files with an unknown extension, e.g. data or docs, aren't worth
+	// opening
+	if !ok && filepath.Ext(name) != "" && !strings.HasPrefix(name, ".") {
+		return ""
+	}
+
+	head := readHead(path)
+	if isGenerated(head) {
+		return ""
+	}
+	if ok {
+		return lang
+	}
+	return shebang(head)
+}
+
+func readHead(path string) []byte {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	head := make([]byte, headSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil
+	}
+	return head[:n]
+}
+
+// isGenerated looks for the markers code generators leave at the top of
+// their output, e.g. Go's "Code generated ... DO NOT EDIT.".
+func isGenerated(head []byte) bool {
+	return (bytes.Contains(head, []byte("Code generated")) && bytes.Contains(head, []byte("DO NOT EDIT"))) ||
+		bytes.Contains(head, []byte("@generated")) ||
+		bytes.Contains(head, []byte("<auto-generated"))
+}
+
+// shebang returns the language of the interpreter named by head's #! line,
+// looking through env and version suffixes such as python3.11.
+func shebang(head []byte) string {
+	if !bytes.HasPrefix(head, []byte("#!")) {
+		return ""
+	}
+
+	line, _, _ := bufio.NewReader(bytes.NewReader(head[2:])).ReadLine()
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	program := filepath.Base(fields[0])
+	if program == "env" {
+		program = ""
+		for _, f := range fields[1:] {
+			// skip env's own flags and VAR=value assignments
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+				program = f
+				break
+			}
+		}
+	}
+
+	if lang, ok := interpreters[program]; ok {
+		return lang
+	}
+	return interpreters[strings.TrimRight(program, "0123456789.")]
+}
@@ -0,0 +1,111 @@
+package linguist
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShebang(t *testing.T) {
+	tests := []struct {
+		head, want string
+	}{
+		{head: "#!/bin/sh\n", want: "Shell"},
+		{head: "#!/bin/bash\r\necho hi\n", want: "Shell"},
+		{head: "#! /bin/bash\n", want: "Shell"},
+		{head: "#!/usr/bin/env node\n", want: "JavaScript"},
+		{head: "#!/usr/bin/env python3.11\n", want: "Python"},
+		{head: "#!/usr/local/bin/python3.11 -O\n", want: "Python"},
+		{head: "#!/usr/bin/env -S python3 -u\n", want: "Python"},
+		{head: "#!/usr/bin/env -S node --harmony\n", want: "JavaScript"},
+		{head: "#!/usr/bin/env RUBYOPT=-w ruby\n", want: "Ruby"},
+		{head: "#!/usr/bin/env\n", want: ""},
+		{head: "#!\n", want: ""},
+		{head: "#!/usr/bin/unknown\n", want: ""},
+		{head: "echo no shebang\n", want: ""},
+		{head: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := shebang([]byte(tt.head)); got != tt.want {
+			t.Errorf("shebang(%q) = %q, want %q", tt.head, got, tt.want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	dir := t.TempDir()
+	big := strings.Repeat("x", 4096)
+
+	// counted
+	writeFile(t, dir, "main.go", "package main\n"+strings.Repeat("/", 187))
+	writeFile(t, dir, "bin/tool", "#!/usr/bin/env -S python3 -u\n"+strings.Repeat("#", 21))
+
+	// vendored, generated, ignored, excluded and nested repos are not
+	writeFile(t, dir, "vendor/lib/lib.py", big)
+	writeFile(t, dir, "web/node_modules/x/index.js", big)
+	writeFile(t, dir, "api.pb.go", big)
+	writeFile(t, dir, "app.min.js", big)
+	writeFile(t, dir, "gen.py", "# Code generated by tool. DO NOT EDIT.\n"+big)
+	writeFile(t, dir, "schema.rs", "// @generated\n"+big)
+	writeFile(t, dir, ".gitignore", "build/\n*.tmp.rb\n")
+	writeFile(t, dir, "build/out.rs", big)
+	writeFile(t, dir, "scratch.tmp.rb", big)
+	writeFile(t, dir, ".git/info/exclude", "notes.py\n")
+	writeFile(t, dir, "notes.py", big)
+	writeFile(t, dir, "submodule/.git", "gitdir: ../.git/modules/submodule\n")
+	writeFile(t, dir, "submodule/lib.rs", big)
+
+	// not code
+	writeFile(t, dir, "data.csv", big)
+	writeFile(t, dir, "empty.rs", "")
+
+	sizes, err := Breakdown(dir)
+	if err != nil {
+		t.Fatalf("Breakdown failed: %v", err)
+	}
+	want := map[string]int64{"Go": 200, "Python": 50}
+	if !reflect.DeepEqual(sizes, want) {
+		t.Errorf("Breakdown = %v, want %v", sizes, want)
+	}
+
+	if lang, err := Detect(dir); err != nil || lang != "Go" {
+		t.Errorf("Detect = %q, %v, want Go", lang, err)
+	}
+}
+
+func TestDetectTies(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.rs", "fn main() {}\n")
+	writeFile(t, dir, "a.go", "package main\n")
+
+	if lang, err := Detect(dir); err != nil || lang != "Go" {
+		t.Errorf("Detect = %q, %v, want Go", lang, err)
+	}
+}
+
+func TestDetectNothing(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "README", "nothing to see\n")
+
+	if lang, err := Detect(dir); err != nil || lang != "" {
+		t.Errorf("Detect = %q, %v, want no language", lang, err)
+	}
+
+	if _, err := Detect(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Detect of a missing directory succeeded")
+	}
+}
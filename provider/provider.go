@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	Github = "github"
+	Gitlab = "gitlab"
+	Gitea  = "gitea"
+	// Forgejo is a Gitea fork with the same API.
+	Forgejo = "forgejo"
+)
+
+type (
+	// Provider is a git hosting service sgit can list, create and delete
+	// repositories on.
+	Provider interface {
+		// Host is the web and ssh host repositories are served from, it
+		// namespaces the repos on disk.
+		Host() string
+		// GetUsername returns the login of the account the token belongs to.
+		GetUsername(ctx context.Context) (string, error)
+		// GetAllRepos returns every repository visible to the account.
+		// Language may be left empty when it is expensive to look up, in
+		// which case callers fall back to GetPrimaryLanguageForRepo. When
+		// some pages fail the repos that were fetched are returned along
+		// with the error.
+		GetAllRepos(ctx context.Context) ([]Repository, error)
+		GetPrimaryLanguageForRepo(ctx context.Context, owner, name string) (string, error)
+		CreateRepo(ctx context.Context, name string, private bool) (*Repository, error)
+		DeleteRepo(ctx context.Context, owner, name string) error
+	}
+
+	Repository struct {
+		Name, Owner, SshUrl, Language string
+		Fork                          bool
+		// PushedAt is the last push, or the closest thing the host tracks.
+		PushedAt time.Time
+		// Size is in kilobytes.
+		Size int64
+	}
+)